@@ -1,52 +1,384 @@
 package cmd
 
 import (
+	"bufio"
+	"context"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strings"
+	"syscall"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-isatty"
+	"github.com/smilepakawat/goat/internal/answers"
 	"github.com/smilepakawat/goat/internal/generator"
+	"github.com/smilepakawat/goat/internal/gitrepo"
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/registry"
+	"github.com/smilepakawat/goat/internal/remote"
 	"github.com/smilepakawat/goat/internal/ui"
 	"github.com/spf13/cobra"
 )
 
-func createProject(use string, short string, long string, templates []string) *cobra.Command {
-	return &cobra.Command{
+type createOptions struct {
+	name    string
+	module  string
+	yes     bool
+	answers string
+	set     map[string]string
+
+	templateDir   string
+	keepOnFailure bool
+	dryRun        bool
+	dryRunFormat  string
+
+	into     string
+	force    bool
+	conflict string
+
+	overlays []string
+	noHooks  bool
+
+	git       bool
+	gitBranch string
+	offline   bool
+}
+
+func createProject(use string, short string, long string, templateID string) *cobra.Command {
+	opts := &createOptions{}
+	command := &cobra.Command{
 		Use:   use,
 		Short: short,
 		Long:  long,
+		Args:  cobra.NoArgs,
 		Run: func(cmd *cobra.Command, args []string) {
-			p := tea.NewProgram(ui.NewInitModel())
-			teaModel, err := p.Run()
-			if err != nil {
-				fmt.Printf("Error, there's been an error: %v", err)
-				os.Exit(1)
-			}
+			runCreate(templateID, opts)
+		},
+	}
+	opts.addFlags(command)
+	return command
+}
 
-			model, _ := teaModel.(ui.Model)
-			projectName := model.ProjectInput.Value()
-			moduleName := model.ModuleInput.Value()
-			if projectName == "" {
-				fmt.Println("Error: Project name is required.")
-				os.Exit(1)
-			}
-			if moduleName == "" {
-				fmt.Println("Error: Module name is required.")
-				os.Exit(1)
-			}
+func runCreate(templateID string, opts *createOptions) {
+	templates, err := registry.NewOverlay(registry.SearchPath(opts.templateDir)...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	source := lock.Template{ID: templateID}
+	if remote.IsRemote(templateID) {
+		rev, err := fetchTemplate(templateID)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		templates.AddTemplate(rev.ID(), rev.Dir, rev.Spec.String())
+		source = lock.Template{ID: rev.ID(), Source: rev.Spec.String(), Commit: rev.Commit}
+	}
 
-			config := generator.ProjectConfig{
-				ProjectName: projectName,
-				ModuleName:  moduleName,
-				Templates:   templates,
-			}
-			err = config.GenerateProject()
-			if err != nil {
-				fmt.Printf("Error generating project: %v\n", err)
-				os.Exit(1)
+	reg, err := registry.Load(templates)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	tmpl, err := reg.Get(source.ID)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	if tmpl.Manifest.Abstract {
+		fmt.Printf("Error: template %s can only be extended by other templates\n", tmpl.ID)
+		os.Exit(1)
+	}
+	layers, err := reg.Layers(source.ID, opts.overlays...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	source.Source = tmpl.Source
+	source.Version = tmpl.Manifest.Version
+	source.Overlays = opts.overlays
+
+	config, err := opts.projectConfig(source.ID, layers, templates)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	config.Source = source
+
+	if opts.dryRun {
+		if err := dryRun(config, opts.dryRunFormat); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	hooks := config.Manifest.Hooks
+	if opts.noHooks {
+		printSkippedHooks(hooks)
+		hooks = manifest.Hooks{}
+	}
+	// Ctrl-C stops the running command, the project is then cleaned up below.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := config.RunHooks(ctx, hooks.Pre, "."); err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(exitCode(err))
+	}
+
+	_, statErr := os.Stat(config.Dir())
+	existed := statErr == nil
+
+	err = config.GenerateProject()
+	if err != nil {
+		fmt.Printf("Error generating project: %v\n", err)
+		os.Exit(1)
+	}
+
+	// go get and the post hooks may change generated files such as go.mod,
+	// the lock then records their final content instead of the rendered one.
+	pristine, err := lock.Pristine(config.Dir())
+	if err == nil {
+		err = config.ResolveDependencies(ctx, config.Dir())
+	}
+	if err == nil {
+		err = config.RunHooks(ctx, hooks.Post, config.Dir())
+	}
+	if err == nil {
+		err = lock.Rehash(config.Dir(), pristine)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		if !opts.keepOnFailure && !existed {
+			os.RemoveAll(config.Dir())
+			fmt.Printf("Removed %s, rerun with --keep-on-failure to inspect it.\n", config.Dir())
+		}
+		os.Exit(exitCode(err))
+	}
+
+	if opts.git {
+		initRepository(ctx, config.Dir(), opts.gitBranch)
+	}
+
+	fmt.Printf("Project '%s' created successfully!\n", config.ProjectName)
+	fmt.Printf("Next steps:\n")
+	fmt.Printf("  cd %s\n", config.Dir())
+	fmt.Printf("  go run main.go\n")
+}
+
+// initRepository runs the --git step. The project is complete at this point,
+// so problems are reported without failing goat.
+func initRepository(ctx context.Context, dir, branch string) {
+	result, err := gitrepo.Init(ctx, dir, gitrepo.Options{Branch: branch})
+	switch {
+	case errors.Is(err, gitrepo.ErrNotFound):
+		fmt.Println("Skipped git init: git is not installed")
+	case err != nil:
+		fmt.Printf("Warning: failed to initialize git repository: %v\n", err)
+	case result.InsideRepo:
+		fmt.Printf("Skipped git init: %s is already inside a git repository\n", dir)
+	case result.Commit == "":
+		fmt.Printf("Initialized git repository on branch %s, the files are staged but not committed because git has no user.name and user.email\n", result.Branch)
+	default:
+		fmt.Printf("Initialized git repository on branch %s with commit %.7s\n", result.Branch, result.Commit)
+	}
+}
+
+// printSkippedHooks lists the hooks --no-hooks skipped, so they can be run by
+// hand once the template is trusted.
+func printSkippedHooks(hooks manifest.Hooks) {
+	for _, hook := range hooks.Pre {
+		fmt.Printf("Skipped pre hook '%s' (--no-hooks)\n", hook)
+	}
+	for _, hook := range hooks.Post {
+		fmt.Printf("Skipped post hook '%s' (--no-hooks)\n", hook)
+	}
+}
+
+func (opts *createOptions) addFlags(command *cobra.Command) {
+	command.Flags().StringVar(&opts.name, "name", "", "project name (skips the interactive wizard together with --module)")
+	command.Flags().StringVar(&opts.module, "module", "", "Go module path (skips the interactive wizard together with --name)")
+	command.Flags().BoolVarP(&opts.yes, "yes", "y", false, "never prompt; fail if a required value is missing")
+	command.Flags().StringVar(&opts.answers, "answers", "", "YAML or JSON file with the answers for every value; implies --yes")
+	command.Flags().StringVar(&opts.templateDir, "template-dir", "", "directory laid out like pkg/templates whose templates override the built-in ones (see also "+registry.PathEnv+")")
+	command.Flags().BoolVar(&opts.keepOnFailure, "keep-on-failure", false, "keep the partially generated project when a step fails")
+	command.Flags().BoolVar(&opts.dryRun, "dry-run", false, "render the project in memory and print what would be created, without writing anything")
+	command.Flags().StringVar(&opts.dryRunFormat, "dry-run-format", "tree", "dry-run output: tree, contents or tar")
+	command.Flags().StringVar(&opts.into, "into", "", "generate into this directory, which may already exist (e.g. --into . for a freshly cloned repo)")
+	command.Flags().BoolVar(&opts.force, "force", false, "generate into an existing directory, overwriting conflicting files unless --conflict says otherwise")
+	command.Flags().StringVar(&opts.conflict, "conflict", "", "what to do with existing files: skip (default with --into), overwrite (default with --force), prompt or sidecar (write <file>"+generator.SidecarSuffix+")")
+	command.Flags().StringSliceVar(&opts.overlays, "overlay", nil, "template to layer on top of the chosen one, e.g. company files such as CODEOWNERS; may be repeated")
+	command.Flags().BoolVar(&opts.noHooks, "no-hooks", false, "do not run the pre and post hooks of the template, e.g. for templates you do not trust")
+	command.Flags().BoolVar(&opts.git, "git", false, "initialize a git repository and commit the project, unless it is inside one already")
+	command.Flags().StringVar(&opts.gitBranch, "git-branch", "", "initial branch of the repository with --git (default: init.defaultBranch from the git config, or "+gitrepo.DefaultBranch+")")
+	command.Flags().BoolVar(&opts.offline, "offline", false, "resolve dependencies from the local module cache only, without proxy, checksum database or toolchain downloads")
+	command.Flags().StringToStringVar(&opts.set, "set", nil, "template variable as name=value, may be repeated")
+}
+
+func dryRun(config generator.ProjectConfig, format string) error {
+	files, err := config.Render()
+	if err != nil {
+		return err
+	}
+	switch format {
+	case "tree":
+		return generator.WriteTree(os.Stdout, config.Dir(), files)
+	case "contents":
+		return generator.WriteContents(os.Stdout, config.Dir(), files)
+	case "tar":
+		if isTerminal(os.Stdout) {
+			return errors.New("refusing to write a tar stream to a terminal, redirect the output to a file")
+		}
+		return generator.WriteTar(os.Stdout, config.Dir(), files)
+	}
+	return fmt.Errorf("unknown dry-run format %q, expected tree, contents or tar", format)
+}
+
+func (opts *createOptions) projectConfig(id string, layers []registry.Template, templates fs.FS) (generator.ProjectConfig, error) {
+	config := generator.ProjectConfig{FS: templates, KeepOnFailure: opts.keepOnFailure, OutputDir: opts.into, Offline: opts.offline}
+	if err := config.ApplyLayers(generatorLayers(layers, id)...); err != nil {
+		return config, err
+	}
+	conflict, err := opts.conflictStrategy()
+	if err != nil {
+		return config, err
+	}
+	config.Conflict = conflict
+	if conflict == generator.ConflictPrompt {
+		config.Prompt = promptConflict
+	}
+	if opts.into != "" {
+		abs, err := filepath.Abs(opts.into)
+		if err != nil {
+			return config, err
+		}
+		config.ProjectName = filepath.Base(abs)
+	}
+	if opts.answers != "" {
+		fileAnswers, err := answers.Load(opts.answers)
+		if err != nil {
+			return config, err
+		}
+		fileAnswers.Apply(&config)
+	}
+	if opts.name != "" {
+		config.ProjectName = opts.name
+	}
+	if opts.module != "" {
+		config.ModuleName = opts.module
+	}
+	if len(opts.set) != 0 && config.Variables == nil {
+		config.Variables = make(map[string]any, len(opts.set))
+	}
+	for name, value := range opts.set {
+		config.Variables[name] = value
+	}
+
+	if opts.interactive(config) {
+		if !isTerminal(os.Stdin) {
+			return config, errors.New("stdin is not a terminal, pass --name and --module to run non-interactively")
+		}
+		model, err := runWizard(config)
+		if err != nil {
+			return config, err
+		}
+		config.ProjectName = model.ProjectInput.Value()
+		config.ModuleName = model.ModuleInput.Value()
+		for name, value := range model.Answers() {
+			if config.Variables == nil {
+				config.Variables = make(map[string]any)
 			}
+			config.Variables[name] = value
+		}
+	}
 
-			RunCmd(config.ProjectName, "go", "mod", "tidy")
-		},
+	return config.Resolve()
+}
+
+// generatorLayers marks the templates that come after the selected one id,
+// i.e. the requested overlays and what they extend, as overlays.
+func generatorLayers(templates []registry.Template, id string) []generator.Layer {
+	selected := slices.IndexFunc(templates, func(tmpl registry.Template) bool { return tmpl.ID == id })
+	layers := make([]generator.Layer, 0, len(templates))
+	for i, tmpl := range templates {
+		layers = append(layers, generator.Layer{Dir: tmpl.Dir, Manifest: tmpl.Manifest, Overlay: i > selected})
+	}
+	return layers
+}
+
+func (opts *createOptions) conflictStrategy() (generator.Conflict, error) {
+	if opts.conflict != "" {
+		if opts.into == "" && !opts.force {
+			return "", errors.New("--conflict requires --into or --force")
+		}
+		return generator.ParseConflict(opts.conflict)
+	}
+	if opts.force {
+		return generator.ConflictOverwrite, nil
 	}
+	if opts.into != "" {
+		return generator.ConflictSkip, nil
+	}
+	return "", nil
+}
+
+// promptConflict asks what to do with a file that exists, reading the answer
+// from the terminal or from answers piped to goat, one per line.
+func promptConflict(in *bufio.Reader, path string) (generator.Conflict, error) {
+	for {
+		fmt.Printf("%s already exists: [s]kip, [o]verwrite or write [n]ew file next to it? ", path)
+		line, err := in.ReadString('\n')
+		if err != nil {
+			return "", fmt.Errorf("failed to read answer: %w", err)
+		}
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "s", "skip":
+			return generator.ConflictSkip, nil
+		case "o", "overwrite":
+			return generator.ConflictOverwrite, nil
+		case "n", "new":
+			return generator.ConflictSidecar, nil
+		}
+	}
+}
+
+// interactive tells whether the wizard has to ask for the project name or
+// module, which may also come from --into or an answers file.
+func (opts *createOptions) interactive(config generator.ProjectConfig) bool {
+	if opts.yes || opts.answers != "" {
+		return false
+	}
+	return config.ProjectName == "" || config.ModuleName == ""
+}
+
+func runWizard(config generator.ProjectConfig) (ui.Model, error) {
+	initModel := ui.NewInitModel()
+	initModel.ProjectInput.SetValue(config.ProjectName)
+	initModel.ModuleInput.SetValue(config.ModuleName)
+	for _, v := range config.Manifest.Variables {
+		if _, ok := config.Variables[v.Name]; !ok {
+			initModel.AddVariables(v)
+		}
+	}
+
+	p := tea.NewProgram(initModel)
+	teaModel, err := p.Run()
+	if err != nil {
+		return ui.Model{}, fmt.Errorf("there's been an error: %w", err)
+	}
+
+	model, _ := teaModel.(ui.Model)
+	return model, nil
+}
+
+func isTerminal(f *os.File) bool {
+	return isatty.IsTerminal(f.Fd()) || isatty.IsCygwinTerminal(f.Fd())
 }
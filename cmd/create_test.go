@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/smilepakawat/goat/internal/generator"
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/registry"
+)
+
+func TestConflictStrategy(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          createOptions
+		expectedValue generator.Conflict
+		wantErr       bool
+	}{
+		{name: "new directory", opts: createOptions{}, expectedValue: ""},
+		{name: "into defaults to skip", opts: createOptions{into: "."}, expectedValue: generator.ConflictSkip},
+		{name: "force defaults to overwrite", opts: createOptions{force: true}, expectedValue: generator.ConflictOverwrite},
+		{name: "explicit strategy", opts: createOptions{into: ".", conflict: "sidecar"}, expectedValue: generator.ConflictSidecar},
+		{name: "strategy without into or force", opts: createOptions{conflict: "skip"}, wantErr: true},
+		{name: "unknown strategy", opts: createOptions{into: ".", conflict: "merge"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := tt.opts.conflictStrategy()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("conflictStrategy() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	tests := []struct {
+		name          string
+		opts          createOptions
+		config        generator.ProjectConfig
+		expectedValue bool
+	}{
+		{name: "nothing given", expectedValue: true},
+		{name: "only the module", config: generator.ProjectConfig{ModuleName: "github.com/test/svc"}, expectedValue: true},
+		{name: "name from into and module", config: generator.ProjectConfig{ProjectName: "svc", ModuleName: "github.com/test/svc"}, expectedValue: false},
+		{name: "yes", opts: createOptions{yes: true}, expectedValue: false},
+		{name: "answers file", opts: createOptions{answers: "answers.yaml"}, expectedValue: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.opts.interactive(tt.config); actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestProjectConfig_IntoWithModule(t *testing.T) {
+	into := filepath.Join(t.TempDir(), "svc")
+	opts := createOptions{into: into, module: "github.com/test/svc"}
+	layers := []registry.Template{{ID: "api", Dir: "templates/api", Manifest: manifest.Manifest{
+		Name:  "API",
+		Files: []manifest.File{{Src: "main.go.tmpl"}},
+	}}}
+	templates := fstest.MapFS{"templates/api/main.go.tmpl": {Data: []byte("package main")}}
+
+	config, err := opts.projectConfig("api", layers, templates)
+	if err != nil {
+		t.Fatalf("projectConfig() error = %v", err)
+	}
+	if config.ProjectName != "svc" || config.ModuleName != "github.com/test/svc" {
+		t.Errorf("Value not match\nactual = %v %v\nexpected = %v %v", config.ProjectName, config.ModuleName, "svc", "github.com/test/svc")
+	}
+}
+
+func TestGeneratorLayers(t *testing.T) {
+	templates := []registry.Template{
+		{ID: "base", Dir: "templates/base"},
+		{ID: "gin", Dir: "templates/gin"},
+		{ID: "company", Dir: "company/company"},
+	}
+
+	actual := generatorLayers(templates, "gin")
+
+	expected := []generator.Layer{
+		{Dir: "templates/base"},
+		{Dir: "templates/gin"},
+		{Dir: "company/company", Overlay: true},
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Value not match\nactual = %+v\nexpected = %+v", actual, expected)
+	}
+}
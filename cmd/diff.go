@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/smilepakawat/goat/internal/diff"
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/registry"
+	"github.com/spf13/cobra"
+)
+
+type diffOptions struct {
+	dir         string
+	templateDir string
+	stat        bool
+	exitCode    bool
+}
+
+var diffCmd = newDiff()
+
+func newDiff() *cobra.Command {
+	opts := &diffOptions{}
+	command := &cobra.Command{
+		Use:   "diff",
+		Short: "Show how a project differs from its template",
+		Long: `Lists the files whose content no longer matches the hash recorded in .goat.lock,
+i.e. the files that were customized since the project was generated, and shows a unified
+diff of each against the template recorded in .goat.lock, re-rendered with the recorded
+answers. Files that were not changed since they were generated are not shown.
+
+Git templates are rendered at the recorded commit, built-in and local templates in their
+current version.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runDiff(opts)
+		},
+	}
+	command.Flags().StringVar(&opts.dir, "dir", ".", "project directory")
+	command.Flags().StringVar(&opts.templateDir, "template-dir", "", "directory laid out like pkg/templates whose templates override the built-in ones (see also "+registry.PathEnv+")")
+	command.Flags().BoolVar(&opts.stat, "stat", false, "only list every generated file as pristine, modified or deleted, without rendering the template")
+	command.Flags().BoolVar(&opts.exitCode, "exit-code", false, "exit with 1 when the project differs from its template")
+	return command
+}
+
+func runDiff(opts *diffOptions) {
+	recorded, err := lock.Read(opts.dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	// The template is only rendered for the patches, whether a file was
+	// customized is told by the hash it was generated with.
+	var rendered map[string][]byte
+	if !opts.stat {
+		files, err := renderRecordedRevision(recorded, opts.templateDir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		rendered = make(map[string][]byte, len(files))
+		for _, f := range files {
+			rendered[f.Path] = f.Content
+		}
+	}
+
+	changed := 0
+	for _, p := range slices.Sorted(maps.Keys(recorded.Files)) {
+		current, err := os.ReadFile(filepath.Join(opts.dir, filepath.FromSlash(p)))
+		deleted := errors.Is(err, os.ErrNotExist)
+		if err != nil && !deleted {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		modified := !deleted && lock.Hash(current) != recorded.Files[p]
+		if deleted || modified {
+			changed++
+		}
+
+		switch {
+		case !opts.stat:
+			if !deleted && !modified {
+				continue
+			}
+			oldName, newName := "a/"+p, "b/"+p
+			template, ok := rendered[p]
+			if !ok {
+				oldName = "/dev/null"
+			}
+			if deleted {
+				newName = "/dev/null"
+			}
+			fmt.Print(diff.Unified(oldName, newName, template, current))
+		case deleted:
+			fmt.Printf("deleted   %s\n", p)
+		case modified:
+			fmt.Printf("modified  %s\n", p)
+		default:
+			fmt.Printf("pristine  %s\n", p)
+		}
+	}
+
+	if opts.exitCode && changed != 0 {
+		os.Exit(1)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+}
@@ -0,0 +1,100 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/smilepakawat/goat/internal/registry"
+	"github.com/smilepakawat/goat/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+var newCmd = newProject()
+
+func newProject() *cobra.Command {
+	opts := &createOptions{}
+	command := &cobra.Command{
+		Use:   "new <template>",
+		Short: "Create a new project from a template",
+		Long: `Creates a new project from any template found in the template registry.
+Every directory with a goat.yaml manifest is a template, its id is the directory name.
+
+Templates can also be fetched from a git repository, pinned to a tag, branch or commit:
+
+  goat new git+https://github.com/org/templates//api@v1.2.0
+  goat new git+file:///srv/git/templates.git//api@main`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			if len(args) == 1 {
+				return nil
+			}
+			reg, err := loadRegistry(opts.templateDir)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("requires exactly one template id, available templates:\n%s", describeTemplates(reg))
+		},
+		ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			if len(args) != 0 {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			reg, err := loadRegistry(opts.templateDir)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveError
+			}
+			return reg.IDs(), cobra.ShellCompDirectiveNoFileComp
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			runCreate(args[0], opts)
+		},
+	}
+	opts.addFlags(command)
+	return command
+}
+
+func loadRegistry(templateDir string) (*registry.Registry, error) {
+	templates, err := registry.NewOverlay(registry.SearchPath(templateDir)...)
+	if err != nil {
+		return nil, err
+	}
+	return registry.Load(templates)
+}
+
+func fetchTemplate(source string) (remote.Revision, error) {
+	spec, err := remote.ParseSpec(source)
+	if err != nil {
+		return remote.Revision{}, err
+	}
+	cacheDir, err := remote.CacheDir()
+	if err != nil {
+		return remote.Revision{}, err
+	}
+
+	fmt.Fprintf(os.Stderr, "Fetching template %s...\n", spec)
+	rev, err := remote.Fetch(context.Background(), cacheDir, spec)
+	if err != nil {
+		return remote.Revision{}, err
+	}
+	if rev.Offline {
+		fmt.Fprintf(os.Stderr, "Could not reach %s, using the cached revision.\n", spec.URL)
+	}
+	fmt.Fprintf(os.Stderr, "Using %s at %s\n", spec, rev.Commit)
+	return rev, nil
+}
+
+func describeTemplates(reg *registry.Registry) string {
+	var sb strings.Builder
+	for _, tmpl := range reg.List() {
+		fmt.Fprintf(&sb, "  %-10s %s", tmpl.ID, tmpl.Manifest.Description)
+		if tmpl.Source != registry.EmbeddedSource {
+			fmt.Fprintf(&sb, " (%s)", tmpl.Source)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+func init() {
+	rootCmd.AddCommand(newCmd)
+}
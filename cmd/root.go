@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/smilepakawat/goat/internal/version"
 	"github.com/spf13/cobra"
 )
 
@@ -12,6 +13,7 @@ var rootCmd = &cobra.Command{
 	Short: "A CLI tool to generate Go Application Tmeplate.",
 	Long: `goat is a simple command-line interface
 to help you quickly bootstrap your Go Applications Template.`,
+	Version: version.Version,
 }
 
 func Execute() {
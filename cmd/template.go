@@ -0,0 +1,110 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/smilepakawat/goat/internal/remote"
+	"github.com/spf13/cobra"
+)
+
+var templateCmd = &cobra.Command{
+	Use:   "template",
+	Short: "Manage templates",
+}
+
+var templateCacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the cache of templates fetched from git",
+	Long: `Templates fetched from git are mirrored into $XDG_CACHE_HOME/goat together with
+an integrity hash of every extracted revision. When a repository cannot be reached
+goat falls back to the cached revisions.`,
+}
+
+var templateCacheListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List cached template revisions",
+	Args:  cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := mustCacheDir()
+		entries, err := remote.List(cacheDir)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(entries) == 0 {
+			fmt.Printf("No cached templates in %s\n", cacheDir)
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "URL\tREF\tCOMMIT\tFETCHED\tINTEGRITY")
+		for _, entry := range entries {
+			integrity := entry.Hash
+			if err := entry.Verify(); err != nil {
+				integrity = "CORRUPTED"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%.12s\t%s\t%s\n", entry.URL, entry.Ref, entry.Commit, entry.FetchedAt.Local().Format("2006-01-02 15:04"), integrity)
+		}
+		w.Flush()
+	},
+}
+
+var templateCacheCleanCmd = &cobra.Command{
+	Use:   "clean [source...]",
+	Short: "Remove cached templates, all of them or only the given sources",
+	Run: func(cmd *cobra.Command, args []string) {
+		cacheDir := mustCacheDir()
+		if len(args) == 0 {
+			if err := remote.Clean(cacheDir, ""); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Removed %s\n", cacheDir)
+			return
+		}
+
+		for _, arg := range args {
+			spec, err := remote.ParseSpec(arg)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			if err := remote.Clean(cacheDir, spec.URL); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Printf("Removed cached %s\n", spec.URL)
+		}
+	},
+}
+
+var templateCachePrefetchCmd = &cobra.Command{
+	Use:   "prefetch <source>...",
+	Short: "Fetch templates into the cache for later offline use",
+	Args:  cobra.MinimumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, arg := range args {
+			if _, err := fetchTemplate(arg); err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
+
+func mustCacheDir() string {
+	cacheDir, err := remote.CacheDir()
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+	return cacheDir
+}
+
+func init() {
+	templateCacheCmd.AddCommand(templateCacheListCmd, templateCacheCleanCmd, templateCachePrefetchCmd)
+	templateCmd.AddCommand(templateCacheCmd)
+	rootCmd.AddCommand(templateCmd)
+}
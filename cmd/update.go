@@ -0,0 +1,217 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+
+	"github.com/smilepakawat/goat/internal/generator"
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/registry"
+	"github.com/smilepakawat/goat/internal/remote"
+	"github.com/smilepakawat/goat/internal/update"
+	"github.com/spf13/cobra"
+)
+
+type updateOptions struct {
+	dir         string
+	to          string
+	templateDir string
+	set         map[string]string
+	reject      bool
+	dryRun      bool
+}
+
+var updateCmd = newUpdate()
+
+func newUpdate() *cobra.Command {
+	opts := &updateOptions{}
+	command := &cobra.Command{
+		Use:   "update",
+		Short: "Re-apply a newer template revision to an existing project",
+		Long: `Reads the .goat.lock of a project, renders the recorded and the current template
+revision with the recorded answers and applies the difference to the project with a
+three-way merge. Edits that clash with template changes are left as conflict markers,
+or as <file>.rej with --reject.
+
+Templates fetched from git are updated to the latest commit of the recorded ref, or to
+the ref given with --to. For built-in and local templates the recorded revision cannot
+be rendered again, files that were edited locally are then merged against the new
+template version as a whole.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			runUpdate(opts)
+		},
+	}
+	command.Flags().StringVar(&opts.dir, "dir", ".", "project directory")
+	command.Flags().StringVar(&opts.to, "to", "", "git ref to update to, defaults to the recorded ref")
+	command.Flags().StringVar(&opts.templateDir, "template-dir", "", "directory laid out like pkg/templates whose templates override the built-in ones (see also "+registry.PathEnv+")")
+	command.Flags().StringToStringVar(&opts.set, "set", nil, "value for a template variable as name=value, e.g. one the new revision added")
+	command.Flags().BoolVar(&opts.reject, "reject", false, "write conflicting template changes to <file>"+update.RejectSuffix+" instead of conflict markers")
+	command.Flags().BoolVar(&opts.dryRun, "dry-run", false, "show what would change without writing anything")
+	return command
+}
+
+func runUpdate(opts *updateOptions) {
+	recorded, err := lock.Read(opts.dir)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	templates, err := registry.NewOverlay(registry.SearchPath(opts.templateDir)...)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	var base []generator.RenderedFile
+	source := recorded.Template
+	if remote.IsRemote(recorded.Template.Source) {
+		base, source, err = fetchRevisions(recorded, opts.to, opts.templateDir, templates)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			os.Exit(1)
+		}
+	} else if opts.to != "" {
+		fmt.Printf("Error: --to needs a template fetched from git, %s comes from %s\n", recorded.Template.ID, recorded.Template.Source)
+		os.Exit(1)
+	}
+
+	next, err := renderRecorded(templates, source, recorded.Answers, opts.set)
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	changes, err := update.Apply(ctx, opts.dir, base, next, recorded, update.Options{Reject: opts.reject, DryRun: opts.dryRun})
+	for _, change := range changes {
+		if change.Note != "" {
+			fmt.Printf("%-9s %s (%s)\n", change.Action, change.Path, change.Note)
+		} else {
+			fmt.Printf("%-9s %s\n", change.Action, change.Path)
+		}
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("Project is up to date with its template.")
+	}
+	if opts.dryRun {
+		fmt.Println("Dry run, nothing was written.")
+		return
+	}
+	if slices.ContainsFunc(changes, func(c update.Change) bool { return c.Action == update.Conflict || c.Action == update.Rejected }) {
+		fmt.Println("Some template changes could not be merged, resolve them before committing.")
+		os.Exit(1)
+	}
+}
+
+// fetchRevisions renders the recorded commit of a git template and mounts
+// the revision to update to into templates.
+func fetchRevisions(recorded lock.Lock, to, templateDir string, templates *registry.Overlay) ([]generator.RenderedFile, lock.Template, error) {
+	spec, err := remote.ParseSpec(recorded.Template.Source)
+	if err != nil {
+		return nil, lock.Template{}, err
+	}
+
+	var base []generator.RenderedFile
+	if recorded.Template.Commit != "" {
+		base, err = renderRecordedRevision(recorded, templateDir)
+		if err != nil {
+			return nil, lock.Template{}, fmt.Errorf("failed to render the recorded revision: %w", err)
+		}
+	}
+
+	if to != "" {
+		spec.Ref = to
+	}
+	rev, err := fetchTemplate(spec.String())
+	if err != nil {
+		return nil, lock.Template{}, err
+	}
+	templates.AddTemplate(rev.ID(), rev.Dir, rev.Spec.String())
+	return base, lock.Template{ID: rev.ID(), Source: rev.Spec.String(), Commit: rev.Commit, Overlays: recorded.Template.Overlays}, nil
+}
+
+// renderRecordedRevision renders the template a project was generated from
+// with its recorded answers. Git templates are rendered at the recorded
+// commit, built-in and local templates in their current version.
+func renderRecordedRevision(recorded lock.Lock, templateDir string) ([]generator.RenderedFile, error) {
+	if !remote.IsRemote(recorded.Template.Source) || recorded.Template.Commit == "" {
+		templates, err := registry.NewOverlay(registry.SearchPath(templateDir)...)
+		if err != nil {
+			return nil, err
+		}
+		return renderRecorded(templates, recorded.Template, recorded.Answers, nil)
+	}
+
+	spec, err := remote.ParseSpec(recorded.Template.Source)
+	if err != nil {
+		return nil, err
+	}
+	spec.Ref = recorded.Template.Commit
+	rev, err := fetchTemplate(spec.String())
+	if err != nil {
+		return nil, err
+	}
+	templates, err := registry.NewOverlay(registry.SearchPath(templateDir)...)
+	if err != nil {
+		return nil, err
+	}
+	templates.AddTemplate(rev.ID(), rev.Dir, recorded.Template.Source)
+	return renderRecorded(templates, recorded.Template, recorded.Answers, nil)
+}
+
+// renderRecorded renders a template in memory with the answers recorded in a
+// lockfile. Variables the template no longer declares are dropped.
+func renderRecorded(templates fs.FS, source lock.Template, answers lock.Answers, set map[string]string) ([]generator.RenderedFile, error) {
+	reg, err := registry.Load(templates)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, err := reg.Get(source.ID)
+	if err != nil {
+		return nil, err
+	}
+	layers, err := reg.Layers(source.ID, source.Overlays...)
+	if err != nil {
+		return nil, err
+	}
+	source.Source = tmpl.Source
+	source.Version = tmpl.Manifest.Version
+
+	config := generator.ProjectConfig{
+		FS:          templates,
+		ProjectName: answers.ProjectName,
+		ModuleName:  answers.ModuleName,
+		Variables:   make(map[string]any),
+		Source:      source,
+	}
+	if err := config.ApplyLayers(generatorLayers(layers, source.ID)...); err != nil {
+		return nil, err
+	}
+	for name, value := range answers.Variables {
+		if slices.ContainsFunc(config.Manifest.Variables, func(v manifest.Variable) bool { return v.Name == name }) {
+			config.Variables[name] = value
+		}
+	}
+	for name, value := range set {
+		config.Variables[name] = value
+	}
+	return config.Render()
+}
+
+func init() {
+	rootCmd.AddCommand(updateCmd)
+}
@@ -0,0 +1,70 @@
+package answers
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/smilepakawat/goat/internal/generator"
+	"gopkg.in/yaml.v3"
+)
+
+type Answers struct {
+	ProjectName string         `yaml:"projectName" json:"projectName"`
+	ModuleName  string         `yaml:"moduleName" json:"moduleName"`
+	Variables   map[string]any `yaml:"variables" json:"variables"`
+}
+
+func Load(path string) (Answers, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Answers{}, fmt.Errorf("failed to read answers file: %w", err)
+	}
+
+	answers, err := Parse(content, filepath.Ext(path))
+	if err != nil {
+		return Answers{}, fmt.Errorf("failed to parse answers file %s: %w", path, err)
+	}
+	return answers, nil
+}
+
+func Parse(content []byte, ext string) (Answers, error) {
+	var answers Answers
+	switch strings.ToLower(ext) {
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&answers); err != nil && !errors.Is(err, io.EOF) {
+			return Answers{}, err
+		}
+	case ".yaml", ".yml":
+		decoder := yaml.NewDecoder(bytes.NewReader(content))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&answers); err != nil && !errors.Is(err, io.EOF) {
+			return Answers{}, err
+		}
+	default:
+		return Answers{}, fmt.Errorf("unsupported answers format %q, use .yaml, .yml or .json", ext)
+	}
+	return answers, nil
+}
+
+func (answers Answers) Apply(config *generator.ProjectConfig) {
+	if answers.ProjectName != "" {
+		config.ProjectName = answers.ProjectName
+	}
+	if answers.ModuleName != "" {
+		config.ModuleName = answers.ModuleName
+	}
+	if len(answers.Variables) != 0 && config.Variables == nil {
+		config.Variables = make(map[string]any, len(answers.Variables))
+	}
+	for name, value := range answers.Variables {
+		config.Variables[name] = value
+	}
+}
@@ -0,0 +1,146 @@
+package answers
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smilepakawat/goat/internal/generator"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		ext           string
+		expectedValue Answers
+		wantErr       string
+	}{
+		{
+			name: "yaml answers",
+			content: `projectName: my-service
+moduleName: github.com/test/my-service
+variables:
+  port: 8080
+`,
+			ext: ".yaml",
+			expectedValue: Answers{
+				ProjectName: "my-service",
+				ModuleName:  "github.com/test/my-service",
+				Variables:   map[string]any{"port": 8080},
+			},
+		},
+		{
+			name:    "json answers",
+			content: `{"projectName": "my-service", "moduleName": "github.com/test/my-service", "variables": {"docker": true}}`,
+			ext:     ".json",
+			expectedValue: Answers{
+				ProjectName: "my-service",
+				ModuleName:  "github.com/test/my-service",
+				Variables:   map[string]any{"docker": true},
+			},
+		},
+		{
+			name:          "empty yaml document",
+			content:       "",
+			ext:           ".yml",
+			expectedValue: Answers{},
+		},
+		{
+			name:    "unknown yaml key",
+			content: "projectName: my-service\nprojectNmae: typo\n",
+			ext:     ".yaml",
+			wantErr: "projectNmae",
+		},
+		{
+			name:    "unknown json key",
+			content: `{"projectName": "my-service", "module": "typo"}`,
+			ext:     ".json",
+			wantErr: "module",
+		},
+		{
+			name:    "unsupported format",
+			content: "projectName = \"my-service\"",
+			ext:     ".toml",
+			wantErr: "unsupported answers format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Parse([]byte(tt.content), tt.ext)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse() error = %v, should contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "goat-answers.yaml")
+	if err := os.WriteFile(path, []byte("projectName: my-service\n"), 0644); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+
+	answers, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if answers.ProjectName != "my-service" {
+		t.Errorf("Expected project name 'my-service', got '%s'", answers.ProjectName)
+	}
+
+	if _, err := Load(filepath.Join(tempDir, "missing.yaml")); err == nil {
+		t.Error("Load() should fail for a missing file")
+	}
+}
+
+func TestApply(t *testing.T) {
+	config := generator.ProjectConfig{
+		ProjectName: "keep-me",
+		Templates:   []string{"templates/fiber/main.go.tmpl"},
+	}
+	answers := Answers{
+		ModuleName: "github.com/test/my-service",
+		Variables:  map[string]any{"port": 8080},
+	}
+
+	answers.Apply(&config)
+
+	expected := generator.ProjectConfig{
+		ProjectName: "keep-me",
+		ModuleName:  "github.com/test/my-service",
+		Templates:   []string{"templates/fiber/main.go.tmpl"},
+		Variables:   map[string]any{"port": 8080},
+	}
+	if !reflect.DeepEqual(config, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", config, expected)
+	}
+}
+
+func TestApply_MissingValuesReportedTogether(t *testing.T) {
+	config := generator.ProjectConfig{}
+	Answers{}.Apply(&config)
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() should report the missing values")
+	}
+	for _, want := range []string{"project name is required", "module name is required"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, should contain %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package diff
+
+import "testing"
+
+func TestUnified(t *testing.T) {
+	tests := []struct {
+		name          string
+		oldContent    string
+		newContent    string
+		expectedValue string
+	}{
+		{
+			name:          "equal",
+			oldContent:    "a\nb\n",
+			newContent:    "a\nb\n",
+			expectedValue: "",
+		},
+		{
+			name:          "changed line",
+			oldContent:    "a\nb\nc\n",
+			newContent:    "a\nB\nc\n",
+			expectedValue: "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n",
+		},
+		{
+			name:          "new file",
+			oldContent:    "",
+			newContent:    "a\n",
+			expectedValue: "--- old\n+++ new\n@@ -0,0 +1 @@\n+a\n",
+		},
+		{
+			name:          "deleted file",
+			oldContent:    "a\nb\n",
+			newContent:    "",
+			expectedValue: "--- old\n+++ new\n@@ -1,2 +0,0 @@\n-a\n-b\n",
+		},
+		{
+			name:          "missing newline at end of file",
+			oldContent:    "a\n",
+			newContent:    "a\nb",
+			expectedValue: "--- old\n+++ new\n@@ -1 +1,2 @@\n a\n+b\n\\ No newline at end of file\n",
+		},
+		{
+			name:          "separate hunks",
+			oldContent:    "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\n12\n",
+			newContent:    "one\n2\n3\n4\n5\n6\n7\n8\n9\n10\n11\ntwelve\n",
+			expectedValue: "--- old\n+++ new\n@@ -1,4 +1,4 @@\n-1\n+one\n 2\n 3\n 4\n@@ -9,4 +9,4 @@\n 9\n 10\n 11\n-12\n+twelve\n",
+		},
+		{
+			name:          "close changes share a hunk",
+			oldContent:    "1\n2\n3\n4\n5\n",
+			newContent:    "one\n2\n3\n4\nfive\n",
+			expectedValue: "--- old\n+++ new\n@@ -1,5 +1,5 @@\n-1\n+one\n 2\n 3\n 4\n-5\n+five\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := Unified("old", "new", []byte(tt.oldContent), []byte(tt.newContent))
+			if actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %q\nexpected = %q", actual, tt.expectedValue)
+			}
+		})
+	}
+}
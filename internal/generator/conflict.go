@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/smilepakawat/goat/internal/lock"
+)
+
+// Conflict is the strategy for a rendered file that already exists with
+// different content when generating into an existing directory.
+type Conflict string
+
+const (
+	ConflictSkip      Conflict = "skip"
+	ConflictOverwrite Conflict = "overwrite"
+	ConflictPrompt    Conflict = "prompt"
+	ConflictSidecar   Conflict = "sidecar"
+)
+
+// SidecarSuffix is appended to the path of a conflicting file to write the
+// rendered version next to the existing one.
+const SidecarSuffix = ".goat-new"
+
+func ParseConflict(s string) (Conflict, error) {
+	switch c := Conflict(s); c {
+	case ConflictSkip, ConflictOverwrite, ConflictPrompt, ConflictSidecar:
+		return c, nil
+	}
+	return "", fmt.Errorf("unknown conflict strategy %q, expected skip, overwrite, prompt or sidecar", s)
+}
+
+// Summary lists the project relative paths touched by generating into an
+// existing directory.
+type Summary struct {
+	Created     []string
+	Overwritten []string
+	Skipped     []string
+	Sidecars    []string
+	Unchanged   []string
+}
+
+func (s Summary) Print(w io.Writer) {
+	for _, group := range []struct {
+		label string
+		paths []string
+	}{
+		{"Created", s.Created},
+		{"Overwritten", s.Overwritten},
+		{"Skipped", s.Skipped},
+		{"Written next to existing file", s.Sidecars},
+		{"Unchanged", s.Unchanged},
+	} {
+		for _, p := range group.paths {
+			fmt.Fprintf(w, "%s: %s\n", group.label, p)
+		}
+	}
+	fmt.Fprintf(w, "%d created, %d overwritten, %d skipped, %d sidecar, %d unchanged\n",
+		len(s.Created), len(s.Overwritten), len(s.Skipped), len(s.Sidecars), len(s.Unchanged))
+}
+
+// generateInto writes the rendered project into the existing directory dir.
+// Every file is rendered before the first one is written, so a template error
+// leaves the directory untouched.
+func (config ProjectConfig) generateInto(dir string) (Summary, error) {
+	var summary Summary
+	files, err := config.Render()
+	if err != nil {
+		return summary, err
+	}
+
+	var in *bufio.Reader
+	for _, f := range files {
+		target := filepath.Join(dir, filepath.FromSlash(f.Path))
+		existing, err := os.ReadFile(target)
+		if errors.Is(err, os.ErrNotExist) {
+			if err := writeFile(target, f.Content); err != nil {
+				return summary, err
+			}
+			summary.Created = append(summary.Created, f.Path)
+			continue
+		}
+		if err != nil {
+			return summary, fmt.Errorf("failed to read %s: %w", target, err)
+		}
+		if bytes.Equal(existing, f.Content) {
+			summary.Unchanged = append(summary.Unchanged, f.Path)
+			continue
+		}
+
+		strategy := config.Conflict
+		if f.Path == lock.FileName {
+			strategy = ConflictOverwrite
+		}
+		if strategy == ConflictPrompt {
+			if config.Prompt == nil {
+				return summary, fmt.Errorf("%s already exists and there is no way to prompt", f.Path)
+			}
+			if in == nil {
+				in = bufio.NewReader(os.Stdin)
+			}
+			if strategy, err = config.Prompt(in, f.Path); err != nil {
+				return summary, err
+			}
+		}
+
+		switch strategy {
+		case ConflictOverwrite:
+			if err := writeFile(target, f.Content); err != nil {
+				return summary, err
+			}
+			summary.Overwritten = append(summary.Overwritten, f.Path)
+		case ConflictSidecar:
+			if err := writeFile(target+SidecarSuffix, f.Content); err != nil {
+				return summary, err
+			}
+			summary.Sidecars = append(summary.Sidecars, f.Path+SidecarSuffix)
+		case ConflictSkip:
+			summary.Skipped = append(summary.Skipped, f.Path)
+		default:
+			return summary, fmt.Errorf("%s already exists: %w", f.Path, os.ErrExist)
+		}
+	}
+	return summary, nil
+}
+
+func writeFile(path string, content []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0666); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
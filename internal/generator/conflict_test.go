@@ -0,0 +1,211 @@
+package generator
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParseConflict(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectedValue Conflict
+		wantErr       bool
+	}{
+		{name: "skip", value: "skip", expectedValue: ConflictSkip},
+		{name: "overwrite", value: "overwrite", expectedValue: ConflictOverwrite},
+		{name: "prompt", value: "prompt", expectedValue: ConflictPrompt},
+		{name: "sidecar", value: "sidecar", expectedValue: ConflictSidecar},
+		{name: "unknown", value: "merge", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseConflict(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseConflict() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestGenerateProject_Into(t *testing.T) {
+	templates := fstest.MapFS{
+		"templates/local/main.go.tmpl":   {Data: []byte("package main\n")},
+		"templates/local/go.mod.tmpl":    {Data: []byte("module {{.ModuleName}}")},
+		"templates/local/README.md.tmpl": {Data: []byte("# {{.ProjectName}}")},
+	}
+
+	tests := []struct {
+		name            string
+		conflict        Conflict
+		prompt          Conflict
+		expectedSummary Summary
+		expectedMain    string
+		wantErr         bool
+	}{
+		{
+			name:            "skip keeps existing files",
+			conflict:        ConflictSkip,
+			expectedSummary: Summary{Created: []string{".goat.lock", "go.mod"}, Skipped: []string{"main.go"}, Unchanged: []string{"README.md"}},
+			expectedMain:    "package custom",
+		},
+		{
+			name:            "overwrite replaces existing files",
+			conflict:        ConflictOverwrite,
+			expectedSummary: Summary{Created: []string{".goat.lock", "go.mod"}, Overwritten: []string{"main.go"}, Unchanged: []string{"README.md"}},
+			expectedMain:    "package main\n",
+		},
+		{
+			name:            "sidecar writes next to existing files",
+			conflict:        ConflictSidecar,
+			expectedSummary: Summary{Created: []string{".goat.lock", "go.mod"}, Sidecars: []string{"main.go.goat-new"}, Unchanged: []string{"README.md"}},
+			expectedMain:    "package custom",
+		},
+		{
+			name:            "prompt asks per file",
+			conflict:        ConflictPrompt,
+			prompt:          ConflictOverwrite,
+			expectedSummary: Summary{Created: []string{".goat.lock", "go.mod"}, Overwritten: []string{"main.go"}, Unchanged: []string{"README.md"}},
+			expectedMain:    "package main\n",
+		},
+		{
+			name:     "prompt without a prompter fails",
+			conflict: ConflictPrompt,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.WriteFile(filepath.Join(dir, "main.go"), []byte("package custom"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(dir, "README.md"), []byte("# intoproject"), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			config := ProjectConfig{
+				ProjectName: "intoproject",
+				ModuleName:  "github.com/test/intoproject",
+				Templates:   []string{"templates/local/README.md.tmpl", "templates/local/go.mod.tmpl", "templates/local/main.go.tmpl"},
+				FS:          templates,
+				OutputDir:   dir,
+				Conflict:    tt.conflict,
+			}
+			if tt.prompt != "" {
+				config.Prompt = func(in *bufio.Reader, path string) (Conflict, error) { return tt.prompt, nil }
+			}
+
+			summary, err := config.generateInto(dir)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("generateInto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(summary, tt.expectedSummary) {
+				t.Errorf("Value not match\nactual = %+v\nexpected = %+v", summary, tt.expectedSummary)
+			}
+
+			content, err := os.ReadFile(filepath.Join(dir, "main.go"))
+			if err != nil {
+				t.Fatalf("Failed to read main.go: %v", err)
+			}
+			if string(content) != tt.expectedMain {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", string(content), tt.expectedMain)
+			}
+		})
+	}
+}
+
+func TestGenerateInto_PromptsShareInput(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"main.go", "README.md"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("custom"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var readers []*bufio.Reader
+	config := ProjectConfig{
+		ProjectName: "intoproject",
+		ModuleName:  "github.com/test/intoproject",
+		Templates:   []string{"templates/local/README.md.tmpl", "templates/local/main.go.tmpl"},
+		FS: fstest.MapFS{
+			"templates/local/main.go.tmpl":   {Data: []byte("package main\n")},
+			"templates/local/README.md.tmpl": {Data: []byte("# {{.ProjectName}}")},
+		},
+		OutputDir: dir,
+		Conflict:  ConflictPrompt,
+		Prompt: func(in *bufio.Reader, path string) (Conflict, error) {
+			readers = append(readers, in)
+			return ConflictSkip, nil
+		},
+	}
+
+	if _, err := config.generateInto(dir); err != nil {
+		t.Fatalf("generateInto() error = %v", err)
+	}
+	if len(readers) != 2 || readers[0] == nil || readers[0] != readers[1] {
+		t.Errorf("Expected both prompts to read from the same reader, got %v", readers)
+	}
+}
+
+func TestGenerateProject_ExistingDirectory(t *testing.T) {
+	dir := t.TempDir()
+	config := ProjectConfig{
+		ProjectName: "existing",
+		ModuleName:  "github.com/test/existing",
+		Templates:   []string{"templates/local/main.go.tmpl"},
+		FS:          fstest.MapFS{"templates/local/main.go.tmpl": {Data: []byte("package main")}},
+		OutputDir:   dir,
+	}
+
+	if err := config.GenerateProject(); !errors.Is(err, os.ErrExist) {
+		t.Errorf("GenerateProject() error = %v, want %v", err, os.ErrExist)
+	}
+
+	config.Conflict = ConflictSkip
+	if err := config.GenerateProject(); err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "main.go")); err != nil {
+		t.Errorf("Expected main.go to be created: %v", err)
+	}
+}
+
+func TestGenerateProject_IntoKeepsDirectoryOnTemplateError(t *testing.T) {
+	dir := t.TempDir()
+	config := ProjectConfig{
+		ProjectName: "broken",
+		ModuleName:  "github.com/test/broken",
+		Templates:   []string{"templates/local/a.go.tmpl", "templates/local/b.go.tmpl"},
+		FS: fstest.MapFS{
+			"templates/local/a.go.tmpl": {Data: []byte("package main")},
+			"templates/local/b.go.tmpl": {Data: []byte("package {{.Missing}}")},
+		},
+		OutputDir: dir,
+		Conflict:  ConflictOverwrite,
+	}
+
+	if err := config.GenerateProject(); err == nil {
+		t.Fatal("GenerateProject() should fail")
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("Expected nothing to be written, found %d entries", len(entries))
+	}
+}
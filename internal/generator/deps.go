@@ -0,0 +1,171 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/runner"
+	"github.com/smilepakawat/goat/internal/version"
+)
+
+// ResolveDependencies adds the dependencies declared by the template to the
+// go.mod in dir with go get, at the newest versions matching their queries.
+// Offline, the queries are matched against the module cache instead.
+func (config ProjectConfig) ResolveDependencies(ctx context.Context, dir string) error {
+	if config.Manifest == nil {
+		return nil
+	}
+	deps, err := config.dependencies(config.Manifest.Dependencies)
+	if err != nil {
+		return err
+	}
+	if len(deps) == 0 {
+		return nil
+	}
+	env := append([]string{modFlags()}, config.goEnv()...)
+
+	args := []string{"get"}
+	for _, d := range deps {
+		if config.Offline {
+			if d.Version, err = cachedVersion(ctx, d.Module, d.Version); err != nil {
+				return err
+			}
+		}
+		args = append(args, d.Query())
+	}
+	fmt.Printf("Resolving dependencies: %s\n", strings.Join(args[1:], " "))
+	if err := runner.Run(ctx, runner.Command{Name: "go", Args: args, Dir: dir, Env: env}); err != nil {
+		return fmt.Errorf("failed to resolve dependencies: %w", err)
+	}
+	return nil
+}
+
+// dependencies returns the dependencies whose when condition is true.
+func (config ProjectConfig) dependencies(all []manifest.Dependency) ([]manifest.Dependency, error) {
+	var deps []manifest.Dependency
+	for _, d := range all {
+		if d.When != "" {
+			ok, err := evaluateCondition(d.When, config)
+			if err != nil {
+				return nil, fmt.Errorf("dependency %s: %w", d.Module, err)
+			}
+			if !ok {
+				continue
+			}
+		}
+		deps = append(deps, d)
+	}
+	return deps, nil
+}
+
+// goEnv is the environment for the go commands goat runs in offline mode:
+// modules are only read from the module cache and neither the checksum
+// database nor a newer toolchain are fetched. It is empty otherwise.
+func (config ProjectConfig) goEnv() []string {
+	if !config.Offline {
+		return nil
+	}
+	return []string{"GOPROXY=off", "GOSUMDB=off", "GOTOOLCHAIN=local"}
+}
+
+// modFlags adds -mod=mod to the GOFLAGS of the user, so go get may update
+// go.mod even when the user's GOFLAGS say otherwise.
+func modFlags() string {
+	return "GOFLAGS=" + strings.TrimSpace(os.Getenv("GOFLAGS")+" -mod=mod")
+}
+
+// cachedVersion returns the newest release of module in the module cache
+// that matches query, like go get would pick online. Exact versions and
+// queries other than latest, a version prefix such as v1 or v1.2 and
+// comparisons such as >=v1.2.0 are returned as they are.
+func cachedVersion(ctx context.Context, module, query string) (string, error) {
+	if query == "" || query == "upgrade" {
+		query = "latest"
+	}
+	if query != "latest" && !isVersionPrefix(query) && !strings.ContainsAny(query, "<>") {
+		return query, nil
+	}
+
+	out, err := runner.Output(ctx, runner.Command{Name: "go", Args: []string{"env", "GOMODCACHE"}})
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the module cache: %w", err)
+	}
+	dir := filepath.Join(strings.TrimSpace(string(out)), "cache", "download", escapeModulePath(module), "@v")
+	entries, _ := os.ReadDir(dir)
+
+	best := ""
+	for _, entry := range entries {
+		v, ok := strings.CutSuffix(entry.Name(), ".zip")
+		if !ok || strings.ContainsAny(v, "-+") || !matchesQuery(v, query) {
+			continue
+		}
+		if cmp, err := version.Compare(v, best); best == "" || (err == nil && cmp > 0) {
+			best = v
+		}
+	}
+	if best == "" {
+		return "", fmt.Errorf("no version of %s matching %s in the module cache, generate once without --offline", module, query)
+	}
+	return best, nil
+}
+
+// isVersionPrefix reports whether query is a major or minor version such as
+// v1 or v1.2, which go get resolves to the newest release starting with it.
+func isVersionPrefix(query string) bool {
+	rest, ok := strings.CutPrefix(query, "v")
+	if !ok || strings.Count(rest, ".") > 1 {
+		return false
+	}
+	for _, field := range strings.Split(rest, ".") {
+		if _, err := strconv.Atoi(field); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesQuery(v, query string) bool {
+	if query == "latest" {
+		return true
+	}
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		bound, ok := strings.CutPrefix(query, op)
+		if !ok {
+			continue
+		}
+		cmp, err := version.Compare(v, bound)
+		if err != nil {
+			return false
+		}
+		switch op {
+		case ">=":
+			return cmp >= 0
+		case "<=":
+			return cmp <= 0
+		case ">":
+			return cmp > 0
+		}
+		return cmp < 0
+	}
+	return strings.HasPrefix(v, query+".")
+}
+
+// escapeModulePath escapes upper case letters like the module cache does,
+// e.g. github.com/Masterminds/semver becomes github.com/!masterminds/semver.
+func escapeModulePath(module string) string {
+	var sb strings.Builder
+	for _, r := range module {
+		if unicode.IsUpper(r) {
+			sb.WriteByte('!')
+			r = unicode.ToLower(r)
+		}
+		sb.WriteRune(r)
+	}
+	return filepath.FromSlash(sb.String())
+}
@@ -0,0 +1,125 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smilepakawat/goat/internal/manifest"
+)
+
+func TestCachedVersion(t *testing.T) {
+	cache := t.TempDir()
+	t.Setenv("GOMODCACHE", cache)
+	versions := filepath.Join(cache, "cache", "download", "github.com", "!masterminds", "semver", "@v")
+	if err := os.MkdirAll(versions, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{
+		"v1.4.2.zip", "v1.5.0.zip", "v1.10.0.zip", "v1.11.0.mod", "v1.12.0-rc.1.zip",
+		"v2.0.0+incompatible.zip", "v0.0.0-20200101000000-abcdef123456.zip", "list",
+	} {
+		if err := os.WriteFile(filepath.Join(versions, name), nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name          string
+		module        string
+		query         string
+		expectedValue string
+		wantErr       bool
+	}{
+		{name: "latest release with sources", module: "github.com/Masterminds/semver", expectedValue: "v1.10.0"},
+		{name: "minor prefix", module: "github.com/Masterminds/semver", query: "v1.5", expectedValue: "v1.5.0"},
+		{name: "upper bound", module: "github.com/Masterminds/semver", query: "<v1.5.0", expectedValue: "v1.4.2"},
+		{name: "exact version is kept", module: "github.com/Masterminds/semver", query: "v1.11.0", expectedValue: "v1.11.0"},
+		{name: "branch is kept", module: "github.com/Masterminds/semver", query: "master", expectedValue: "master"},
+		{name: "no match", module: "github.com/Masterminds/semver", query: "v3", wantErr: true},
+		{name: "module not cached", module: "github.com/gin-gonic/gin", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := cachedVersion(context.Background(), tt.module, tt.query)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("cachedVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestMatchesQuery(t *testing.T) {
+	tests := []struct {
+		version       string
+		query         string
+		expectedValue bool
+	}{
+		{version: "v1.2.3", query: "latest", expectedValue: true},
+		{version: "v1.2.3", query: "v1", expectedValue: true},
+		{version: "v1.2.3", query: "v1.2", expectedValue: true},
+		{version: "v1.20.0", query: "v1.2", expectedValue: false},
+		{version: "v2.0.0", query: "v1", expectedValue: false},
+		{version: "v1.2.3", query: ">=v1.2.3", expectedValue: true},
+		{version: "v1.2.3", query: ">v1.2.3", expectedValue: false},
+		{version: "v1.2.3", query: "<=v1.2", expectedValue: false},
+		{version: "v1.1.9", query: "<v1.2.0", expectedValue: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.version+" "+tt.query, func(t *testing.T) {
+			if actual := matchesQuery(tt.version, tt.query); actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestDependencies_Conditions(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "deps",
+		ModuleName:  "github.com/test/deps",
+		Variables:   map[string]any{"Database": "postgres", "Cache": false},
+	}
+	all := []manifest.Dependency{
+		{Module: "github.com/gin-gonic/gin", Version: "v1"},
+		{Module: "github.com/jackc/pgx/v5", When: `eq .Database "postgres"`},
+		{Module: "github.com/redis/go-redis/v9", When: ".Cache"},
+	}
+
+	deps, err := config.dependencies(all)
+	if err != nil {
+		t.Fatalf("dependencies() error = %v", err)
+	}
+	expected := []manifest.Dependency{all[0], all[1]}
+	if !reflect.DeepEqual(deps, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", deps, expected)
+	}
+}
+
+func TestGoEnv(t *testing.T) {
+	if env := (ProjectConfig{}).goEnv(); len(env) != 0 {
+		t.Fatalf("goEnv() = %v, want nothing online", env)
+	}
+
+	expected := "GOPROXY=off GOSUMDB=off GOTOOLCHAIN=local"
+	if env := (ProjectConfig{Offline: true}).goEnv(); strings.Join(env, " ") != expected {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", env, expected)
+	}
+}
+
+func TestModFlags(t *testing.T) {
+	t.Setenv("GOFLAGS", "-tags=integration -mod=readonly")
+
+	expected := "GOFLAGS=-tags=integration -mod=readonly -mod=mod"
+	if actual := modFlags(); actual != expected {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, expected)
+	}
+}
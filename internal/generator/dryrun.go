@@ -0,0 +1,134 @@
+package generator
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteTree prints the files as a tree below root, with their sizes.
+func WriteTree(w io.Writer, root string, files []RenderedFile) error {
+	if _, err := fmt.Fprintf(w, "%s/\n", root); err != nil {
+		return err
+	}
+
+	printed := make(map[string]bool)
+	total := 0
+	for i, f := range files {
+		total += len(f.Content)
+		segments := strings.Split(f.Path, "/")
+		var indent strings.Builder
+		for depth, name := range segments {
+			entry := strings.Join(segments[:depth+1], "/")
+			last := isLastEntry(files, i, depth)
+			if !printed[entry] {
+				printed[entry] = true
+				connector := "├── "
+				if last {
+					connector = "└── "
+				}
+				line := indent.String() + connector + name + "/"
+				if depth == len(segments)-1 {
+					line = fmt.Sprintf("%s%s%s (%s)", indent.String(), connector, name, formatSize(len(f.Content)))
+				}
+				if _, err := fmt.Fprintln(w, line); err != nil {
+					return err
+				}
+			}
+			if last {
+				indent.WriteString("    ")
+			} else {
+				indent.WriteString("│   ")
+			}
+		}
+	}
+
+	_, err := fmt.Fprintf(w, "\n%d files, %s\n", len(files), formatSize(total))
+	return err
+}
+
+// isLastEntry reports whether the entry at depth of files[i] is the last one
+// in its directory. files must be sorted by path.
+func isLastEntry(files []RenderedFile, i, depth int) bool {
+	segments := strings.Split(files[i].Path, "/")
+	parent := strings.Join(segments[:depth], "/")
+	for _, next := range files[i+1:] {
+		nextSegments := strings.Split(next.Path, "/")
+		if len(nextSegments) > depth && strings.Join(nextSegments[:depth], "/") == parent && nextSegments[depth] != segments[depth] {
+			return false
+		}
+	}
+	return true
+}
+
+// WriteContents prints every file with a header line, for reviewing template changes.
+func WriteContents(w io.Writer, root string, files []RenderedFile) error {
+	for _, f := range files {
+		if _, err := fmt.Fprintf(w, "==> %s <==\n", path.Join(root, f.Path)); err != nil {
+			return err
+		}
+		if _, err := w.Write(f.Content); err != nil {
+			return err
+		}
+		if len(f.Content) > 0 && f.Content[len(f.Content)-1] != '\n' {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteTar streams the files as a tar archive with every entry below root,
+// the project directory. Like tar, the entries of an absolute root are made
+// relative.
+func WriteTar(w io.Writer, root string, files []RenderedFile) error {
+	root = filepath.ToSlash(strings.TrimPrefix(root, filepath.VolumeName(root)))
+	root = path.Clean(strings.TrimLeft(root, "/"))
+	tw := tar.NewWriter(w)
+	modTime := time.Unix(0, 0)
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		name := path.Join(root, f.Path)
+		for dir := path.Dir(name); dir != "." && !dirs[dir]; dir = path.Dir(dir) {
+			dirs[dir] = true
+		}
+	}
+	for _, dir := range sortedKeys(dirs) {
+		header := &tar.Header{Typeflag: tar.TypeDir, Name: dir + "/", Mode: 0755, ModTime: modTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+	}
+	for _, f := range files {
+		header := &tar.Header{Typeflag: tar.TypeReg, Name: path.Join(root, f.Path), Mode: 0644, Size: int64(len(f.Content)), ModTime: modTime}
+		if err := tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.Content); err != nil {
+			return err
+		}
+	}
+	return tw.Close()
+}
+
+func formatSize(size int) string {
+	if size < 1024 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f KB", float64(size)/1024)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
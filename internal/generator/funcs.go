@@ -0,0 +1,316 @@
+package generator
+
+import (
+	"crypto/rand"
+	"fmt"
+	"go/token"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// funcMap is available in every template and templated path. Functions that
+// take the piped value take it as their last argument, e.g.
+// {{ .Name | replace "-" "_" }} or {{ .Port | default 8080 }}.
+var funcMap = template.FuncMap{
+	"lower":       strings.ToLower,
+	"upper":       strings.ToUpper,
+	"camel":       camelCase,
+	"pascal":      pascalCase,
+	"snake":       snakeCase,
+	"kebab":       kebabCase,
+	"plural":      plural,
+	"singular":    singular,
+	"trim":        strings.TrimSpace,
+	"trimPrefix":  func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix":  func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"replace":     func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"default":     defaultValue,
+	"quote":       func(v any) string { return strconv.Quote(fmt.Sprint(v)) },
+	"join":        join,
+	"now":         time.Now,
+	"uuid":        newUUID,
+	"goIdent":     goIdent,
+	"packageName": packageName,
+	"env":         env,
+}
+
+// words splits s into lower case words at separators and case changes, so
+// "my-service", "my_service", "myService" and "MyService" give the same words.
+// Acronyms stay together: "HTTPServer" gives "http" and "server".
+func words(s string) []string {
+	var res []string
+	var current []rune
+	runes := []rune(s)
+	flush := func() {
+		if len(current) != 0 {
+			res = append(res, strings.ToLower(string(current)))
+			current = current[:0]
+		}
+	}
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+		if unicode.IsUpper(r) && i > 0 {
+			prev := runes[i-1]
+			nextLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextLower) {
+				flush()
+			}
+		}
+		current = append(current, r)
+	}
+	flush()
+	return res
+}
+
+func capitalize(s string) string {
+	r := []rune(s)
+	if len(r) == 0 {
+		return s
+	}
+	r[0] = unicode.ToUpper(r[0])
+	return string(r)
+}
+
+// camelCase turns "my-service" into "myService".
+func camelCase(s string) string {
+	w := words(s)
+	for i := 1; i < len(w); i++ {
+		w[i] = capitalize(w[i])
+	}
+	return strings.Join(w, "")
+}
+
+// pascalCase turns "my-service" into "MyService".
+func pascalCase(s string) string {
+	w := words(s)
+	for i := range w {
+		w[i] = capitalize(w[i])
+	}
+	return strings.Join(w, "")
+}
+
+// snakeCase turns "MyService" into "my_service".
+func snakeCase(s string) string {
+	return strings.Join(words(s), "_")
+}
+
+// kebabCase turns "MyService" into "my-service".
+func kebabCase(s string) string {
+	return strings.Join(words(s), "-")
+}
+
+var irregularPlurals = map[string]string{
+	"person": "people",
+	"man":    "men",
+	"woman":  "women",
+	"child":  "children",
+	"mouse":  "mice",
+	"goose":  "geese",
+	"tooth":  "teeth",
+	"foot":   "feet",
+	"knife":  "knives",
+	"life":   "lives",
+	"wife":   "wives",
+	"leaf":   "leaves",
+	"half":   "halves",
+	"wolf":   "wolves",
+	"shelf":  "shelves",
+	"status": "statuses",
+	"alias":  "aliases",
+	"bus":    "buses",
+	"gas":    "gases",
+	"quiz":   "quizzes",
+}
+
+var uncountable = map[string]bool{
+	"data":        true,
+	"info":        true,
+	"information": true,
+	"metadata":    true,
+	"news":        true,
+	"series":      true,
+	"species":     true,
+	"equipment":   true,
+}
+
+// plural returns the English plural of a singular noun, e.g. "category"
+// gives "categories". The case of the first letter is kept.
+func plural(s string) string {
+	r := []rune(s)
+	lower := string(lowerRunes(r))
+	if uncountable[lower] || s == "" {
+		return s
+	}
+	if p, ok := irregularPlurals[lower]; ok {
+		return string(r[:1]) + p[1:]
+	}
+	switch {
+	case hasAnySuffix(lower, "s", "x", "z", "ch", "sh"):
+		return s + "es"
+	case strings.HasSuffix(lower, "y") && len(r) > 1 && !isVowel(r[len(r)-2]):
+		return string(r[:len(r)-1]) + "ies"
+	}
+	return s + "s"
+}
+
+// singular returns the singular of a plural noun, e.g. "categories" gives
+// "category". Plurals ending in -ses or -zes are ambiguous: "houses" and
+// "sizes" only drop the s, the few nouns whose plural adds -es to a single
+// s or z after a vowel, such as bus and quiz, are listed as irregular.
+func singular(s string) string {
+	r := []rune(s)
+	lower := string(lowerRunes(r))
+	if uncountable[lower] || s == "" {
+		return s
+	}
+	for single, p := range irregularPlurals {
+		if lower == p {
+			return string(r[:1]) + single[1:]
+		}
+	}
+	switch {
+	case strings.HasSuffix(lower, "ies") && len(r) > 3:
+		return string(r[:len(r)-3]) + "y"
+	case hasAnySuffix(lower, "sses", "xes", "zzes", "ches", "shes"):
+		return string(r[:len(r)-2])
+	case strings.HasSuffix(lower, "zes") && len(r) > 3 && !isVowel(r[len(r)-4]):
+		return string(r[:len(r)-2])
+	case hasAnySuffix(lower, "ss", "us", "is"):
+		return s
+	case strings.HasSuffix(lower, "s"):
+		return string(r[:len(r)-1])
+	}
+	return s
+}
+
+// lowerRunes lowers every rune of r. Unlike strings.ToLower the result has as
+// many runes as r, so suffixes found in it can be cut from r.
+func lowerRunes(r []rune) []rune {
+	lower := make([]rune, len(r))
+	for i, c := range r {
+		lower[i] = unicode.ToLower(c)
+	}
+	return lower
+}
+
+func hasAnySuffix(s string, suffixes ...string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(s, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("aeiouAEIOU", r)
+}
+
+// envPrefix limits env to variables set for templates, so a template from an
+// untrusted source cannot copy tokens or other secrets into the project.
+const envPrefix = "GOAT_"
+
+// env returns the value of the environment variable name, which must start
+// with envPrefix.
+func env(name string) (string, error) {
+	if !strings.HasPrefix(name, envPrefix) {
+		return "", fmt.Errorf("env: only %s variables can be read, not %s", envPrefix, name)
+	}
+	return os.Getenv(name), nil
+}
+
+// defaultValue returns def when value is empty (nil, "", 0, false or an empty
+// collection).
+func defaultValue(def, value any) any {
+	if value == nil {
+		return def
+	}
+	v := reflect.ValueOf(value)
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.Array, reflect.String:
+		if v.Len() == 0 {
+			return def
+		}
+	default:
+		if v.IsZero() {
+			return def
+		}
+	}
+	return value
+}
+
+// join concatenates the elements of a slice with sep.
+func join(sep string, items any) (string, error) {
+	v := reflect.ValueOf(items)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		return "", fmt.Errorf("join: %T is not a list", items)
+	}
+	parts := make([]string, v.Len())
+	for i := range parts {
+		parts[i] = fmt.Sprint(v.Index(i).Interface())
+	}
+	return strings.Join(parts, sep), nil
+}
+
+// newUUID returns a random version 4 UUID.
+func newUUID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("uuid: %w", err)
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:]), nil
+}
+
+// goIdent turns s into a valid, unexported Go identifier in camel case, e.g.
+// "my-service" gives "myService", "2fa" gives "_2fa" and "type" gives "type_".
+func goIdent(s string) string {
+	ident := camelCase(s)
+	if ident == "" {
+		return "_"
+	}
+	if unicode.IsDigit([]rune(ident)[0]) {
+		ident = "_" + ident
+	}
+	if token.IsKeyword(ident) {
+		ident += "_"
+	}
+	return ident
+}
+
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageName derives the conventional package name from a module or import
+// path, e.g. "github.com/me/go-my-service/v2" gives "myservice".
+func packageName(s string) string {
+	elems := strings.Split(strings.Trim(s, "/"), "/")
+	name := elems[len(elems)-1]
+	if majorVersion.MatchString(name) && len(elems) > 1 {
+		name = elems[len(elems)-2]
+	}
+	name = strings.ToLower(name)
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+
+	var sb strings.Builder
+	for _, r := range name {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			sb.WriteRune(r)
+		}
+	}
+	pkg := sb.String()
+	if pkg == "" || unicode.IsDigit([]rune(pkg)[0]) {
+		pkg = "pkg" + pkg
+	}
+	return pkg
+}
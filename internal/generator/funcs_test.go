@@ -0,0 +1,204 @@
+package generator
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func TestCaseConversion(t *testing.T) {
+	tests := []struct {
+		name           string
+		value          string
+		expectedCamel  string
+		expectedPascal string
+		expectedSnake  string
+		expectedKebab  string
+	}{
+		{name: "kebab", value: "my-service", expectedCamel: "myService", expectedPascal: "MyService", expectedSnake: "my_service", expectedKebab: "my-service"},
+		{name: "snake", value: "my_service", expectedCamel: "myService", expectedPascal: "MyService", expectedSnake: "my_service", expectedKebab: "my-service"},
+		{name: "camel", value: "myService", expectedCamel: "myService", expectedPascal: "MyService", expectedSnake: "my_service", expectedKebab: "my-service"},
+		{name: "pascal", value: "MyService", expectedCamel: "myService", expectedPascal: "MyService", expectedSnake: "my_service", expectedKebab: "my-service"},
+		{name: "acronym", value: "HTTPServer", expectedCamel: "httpServer", expectedPascal: "HttpServer", expectedSnake: "http_server", expectedKebab: "http-server"},
+		{name: "digits and spaces", value: "order v2 api", expectedCamel: "orderV2Api", expectedPascal: "OrderV2Api", expectedSnake: "order_v2_api", expectedKebab: "order-v2-api"},
+		{name: "empty", value: "", expectedCamel: "", expectedPascal: "", expectedSnake: "", expectedKebab: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, c := range []struct {
+				fn       func(string) string
+				expected string
+			}{
+				{camelCase, tt.expectedCamel},
+				{pascalCase, tt.expectedPascal},
+				{snakeCase, tt.expectedSnake},
+				{kebabCase, tt.expectedKebab},
+			} {
+				if actual := c.fn(tt.value); actual != c.expected {
+					t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, c.expected)
+				}
+			}
+		})
+	}
+}
+
+func TestPluralSingular(t *testing.T) {
+	tests := []struct {
+		singular string
+		plural   string
+	}{
+		{singular: "user", plural: "users"},
+		{singular: "category", plural: "categories"},
+		{singular: "key", plural: "keys"},
+		{singular: "address", plural: "addresses"},
+		{singular: "box", plural: "boxes"},
+		{singular: "match", plural: "matches"},
+		{singular: "status", plural: "statuses"},
+		{singular: "bus", plural: "buses"},
+		{singular: "Quiz", plural: "Quizzes"},
+		{singular: "house", plural: "houses"},
+		{singular: "response", plural: "responses"},
+		{singular: "size", plural: "sizes"},
+		{singular: "waltz", plural: "waltzes"},
+		{singular: "buzz", plural: "buzzes"},
+		{singular: "Person", plural: "People"},
+		{singular: "child", plural: "children"},
+		{singular: "leaf", plural: "leaves"},
+		{singular: "data", plural: "data"},
+		{singular: "café", plural: "cafés"},
+		{singular: "Ñandú", plural: "Ñandús"},
+		// Kelvin sign, lower cased to an ASCII k.
+		{singular: "\u212Anife", plural: "\u212Anives"},
+		{singular: "\u212Aey", plural: "\u212Aeys"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.singular, func(t *testing.T) {
+			if actual := plural(tt.singular); actual != tt.plural {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.plural)
+			}
+			if actual := singular(tt.plural); actual != tt.singular {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.singular)
+			}
+		})
+	}
+}
+
+func TestGoIdent(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectedValue string
+	}{
+		{name: "kebab", value: "my-service", expectedValue: "myService"},
+		{name: "leading digit", value: "2fa", expectedValue: "_2fa"},
+		{name: "keyword", value: "type", expectedValue: "type_"},
+		{name: "only symbols", value: "--", expectedValue: "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := goIdent(tt.value); actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestPackageName(t *testing.T) {
+	tests := []struct {
+		name          string
+		value         string
+		expectedValue string
+	}{
+		{name: "module path", value: "github.com/me/my-service", expectedValue: "myservice"},
+		{name: "major version", value: "github.com/me/api/v2", expectedValue: "api"},
+		{name: "go prefix", value: "github.com/redis/go-redis/v9", expectedValue: "redis"},
+		{name: "go suffix", value: "github.com/me/client-go", expectedValue: "client"},
+		{name: "plain name", value: "My_Service", expectedValue: "myservice"},
+		{name: "leading digit", value: "3d", expectedValue: "pkg3d"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := packageName(tt.value); actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestFuncMap(t *testing.T) {
+	t.Setenv("GOAT_TEST_ENV", "from-env")
+
+	data := map[string]any{
+		"Name":  "my-service",
+		"Empty": "",
+		"Port":  0,
+		"Tags":  []string{"a", "b"},
+		"Any":   []any{1, "two"},
+	}
+
+	tests := []struct {
+		name          string
+		template      string
+		expectedValue string
+		wantErr       bool
+	}{
+		{name: "lower", template: `{{ "ABC" | lower }}`, expectedValue: "abc"},
+		{name: "upper", template: `{{ .Name | upper }}`, expectedValue: "MY-SERVICE"},
+		{name: "pascal in pipeline", template: `{{ .Name | pascal }}`, expectedValue: "MyService"},
+		{name: "trim", template: `{{ "  x  " | trim }}`, expectedValue: "x"},
+		{name: "trimPrefix", template: `{{ "v1.2.0" | trimPrefix "v" }}`, expectedValue: "1.2.0"},
+		{name: "trimSuffix", template: `{{ "main.go" | trimSuffix ".go" }}`, expectedValue: "main"},
+		{name: "replace", template: `{{ .Name | replace "-" "_" }}`, expectedValue: "my_service"},
+		{name: "default for empty string", template: `{{ .Empty | default "fallback" }}`, expectedValue: "fallback"},
+		{name: "default for zero", template: `{{ .Port | default 8080 }}`, expectedValue: "8080"},
+		{name: "default keeps value", template: `{{ .Name | default "fallback" }}`, expectedValue: "my-service"},
+		{name: "quote", template: `{{ .Name | quote }}`, expectedValue: `"my-service"`},
+		{name: "join strings", template: `{{ .Tags | join ", " }}`, expectedValue: "a, b"},
+		{name: "join any", template: `{{ join "-" .Any }}`, expectedValue: "1-two"},
+		{name: "join no list", template: `{{ join "-" .Name }}`, wantErr: true},
+		{name: "goIdent", template: `{{ .Name | goIdent }}`, expectedValue: "myService"},
+		{name: "packageName", template: `{{ "github.com/me/my-service" | packageName }}`, expectedValue: "myservice"},
+		{name: "env", template: `{{ env "GOAT_TEST_ENV" }}`, expectedValue: "from-env"},
+		{name: "env without prefix", template: `{{ env "HOME" }}`, wantErr: true},
+		{name: "now", template: `{{ now.Year }}`, expectedValue: time.Now().Format("2006")},
+		{name: "plural", template: `{{ "category" | plural }}`, expectedValue: "categories"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New(tt.name).Funcs(funcMap).Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			var sb strings.Builder
+			err = tmpl.Execute(&sb, data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Execute() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && sb.String() != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", sb.String(), tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestUUID(t *testing.T) {
+	pattern := regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	first, err := newUUID()
+	if err != nil {
+		t.Fatalf("newUUID() error = %v", err)
+	}
+	second, _ := newUUID()
+	if !pattern.MatchString(first) {
+		t.Errorf("%s is not a version 4 UUID", first)
+	}
+	if first == second {
+		t.Errorf("Expected different UUIDs, got %s twice", first)
+	}
+}
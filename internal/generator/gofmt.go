@@ -0,0 +1,247 @@
+package generator
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/scanner"
+	"go/token"
+	"strconv"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// renderGoTemplate renders a template for a .go file and formats the result
+// like gofmt, with the imports grouped into standard library and other
+// packages, the project's own ones among the others. Output that is not
+// valid Go is reported at the template line that produced it.
+func renderGoTemplate(templatePath string, config ProjectConfig) ([]byte, error) {
+	tmpl, err := loadAndParseTemplate(config.templatesFS(), templatePath, config.partialDirs(templatePath)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template %s: %w", templatePath, err)
+	}
+
+	rec := newRecorder(tmpl)
+	if err := executeTemplate(tmpl, rec, config); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", templatePath, err)
+	}
+
+	// Whitespace only output is left to skipEmpty.
+	if len(bytes.TrimSpace(rec.buf.Bytes())) == 0 {
+		return rec.buf.Bytes(), nil
+	}
+	content, err := formatGo(rec.buf.Bytes(), config.ModuleName)
+	var list scanner.ErrorList
+	if errors.As(err, &list) && len(list) > 0 {
+		first := list[0]
+		return nil, fmt.Errorf("%s: invalid Go on rendered line %d: %s\n%s", rec.locate(templatePath, first.Pos), first.Pos.Line, first.Msg, snippet(rec.buf.Bytes(), first.Pos.Line))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to format %s: %w", templatePath, err)
+	}
+	return content, nil
+}
+
+// formatGo formats src like gofmt and splits every import block into a
+// standard library group and a group for everything else, including the
+// packages of module.
+func formatGo(src []byte, module string) ([]byte, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", src, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.IMPORT || !gen.Lparen.IsValid() || hasComments(file, gen) {
+			continue
+		}
+		var std, other []string
+		for _, spec := range gen.Specs {
+			imp := spec.(*ast.ImportSpec)
+			text := string(src[fset.Position(imp.Pos()).Offset:fset.Position(imp.End()).Offset])
+			if isStdImport(imp, module) {
+				std = append(std, text)
+			} else {
+				other = append(other, text)
+			}
+		}
+		groups := make([]string, 0, 2)
+		for _, group := range [][]string{std, other} {
+			if len(group) != 0 {
+				groups = append(groups, "\t"+strings.Join(group, "\n\t"))
+			}
+		}
+		out.Write(src[last:fset.Position(gen.Lparen).Offset])
+		out.WriteString("(\n" + strings.Join(groups, "\n\n") + "\n)")
+		last = fset.Position(gen.Rparen).Offset + 1
+	}
+	out.Write(src[last:])
+
+	return format.Source(out.Bytes())
+}
+
+func hasComments(file *ast.File, decl *ast.GenDecl) bool {
+	for _, group := range file.Comments {
+		if group.Pos() > decl.Lparen && group.End() < decl.Rparen {
+			return true
+		}
+	}
+	return false
+}
+
+// isStdImport reports whether imp is a standard library package, i.e. the
+// first element of its path has no dot and it is not part of module, which
+// may have a path without a dot such as myservice.
+func isStdImport(imp *ast.ImportSpec, module string) bool {
+	p, err := strconv.Unquote(imp.Path.Value)
+	if err != nil {
+		return false
+	}
+	if module != "" && (p == module || strings.HasPrefix(p, module+"/")) {
+		return false
+	}
+	first, _, _ := strings.Cut(p, "/")
+	return !strings.Contains(first, ".")
+}
+
+// recorder collects the output of a template and remembers which text node
+// of which template wrote each part of it.
+//
+// text/template does not report which node produced which output. The
+// recorder relies on the executor passing the Text slice of a TextNode to
+// Write unchanged, and recognizes it by the address of its first byte. This
+// is an implementation detail of text/template; TestRecorder fails if it
+// ever changes, since locate would then only return the template path. The
+// rendered line and the snippet around it are reported either way.
+type recorder struct {
+	buf      bytes.Buffer
+	nodes    map[*byte]textNode
+	segments []segment
+}
+
+type textNode struct {
+	tree *parse.Tree
+	node *parse.TextNode
+}
+
+type segment struct {
+	start int
+	text  *textNode
+}
+
+func newRecorder(tmpl *template.Template) *recorder {
+	rec := &recorder{nodes: make(map[*byte]textNode)}
+	for _, t := range tmpl.Templates() {
+		if t.Tree != nil {
+			rec.collect(t.Tree, t.Tree.Root)
+		}
+	}
+	return rec
+}
+
+func (r *recorder) collect(tree *parse.Tree, node parse.Node) {
+	switch n := node.(type) {
+	case *parse.TextNode:
+		if len(n.Text) != 0 {
+			r.nodes[&n.Text[0]] = textNode{tree: tree, node: n}
+		}
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, child := range n.Nodes {
+			r.collect(tree, child)
+		}
+	case *parse.IfNode:
+		r.collect(tree, n.List)
+		r.collect(tree, n.ElseList)
+	case *parse.RangeNode:
+		r.collect(tree, n.List)
+		r.collect(tree, n.ElseList)
+	case *parse.WithNode:
+		r.collect(tree, n.List)
+		r.collect(tree, n.ElseList)
+	}
+}
+
+// Write records which text node, if any, p is the text of, see recorder.
+func (r *recorder) Write(p []byte) (int, error) {
+	if len(p) != 0 {
+		s := segment{start: r.buf.Len()}
+		if text, ok := r.nodes[&p[0]]; ok {
+			s.text = &text
+		}
+		r.segments = append(r.segments, s)
+	}
+	return r.buf.Write(p)
+}
+
+// locate returns "<template>:<line>" for a position in the output. Output
+// written by an action is attributed to the line of the text before it.
+func (r *recorder) locate(templatePath string, pos token.Position) string {
+	offset := lineOffset(r.buf.Bytes(), pos.Line) + pos.Column - 1
+	for i := len(r.segments) - 1; i >= 0; i-- {
+		s := r.segments[i]
+		if s.start > offset || s.text == nil {
+			continue
+		}
+		text := s.text.node.Text
+		if within := offset - s.start; within < len(text) {
+			text = text[:within]
+		}
+		name := s.text.tree.ParseName
+		if i := strings.LastIndexByte(templatePath, '/'); name == templatePath[i+1:] {
+			name = templatePath
+		}
+		line := nodeLine(s.text.tree, s.text.node) + bytes.Count(text, []byte("\n"))
+		return fmt.Sprintf("%s:%d", name, line)
+	}
+	return templatePath
+}
+
+// snippet returns the rendered lines around line, numbered and with line
+// marked, so an error can be found even without its template line.
+func snippet(src []byte, line int) string {
+	lines := strings.Split(strings.TrimSuffix(string(src), "\n"), "\n")
+	var sb strings.Builder
+	for l := max(line-2, 1); l <= min(line+2, len(lines)); l++ {
+		marker := " "
+		if l == line {
+			marker = ">"
+		}
+		fmt.Fprintf(&sb, "%s %4d | %s\n", marker, l, lines[l-1])
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// lineOffset returns the offset of the start of line in src.
+func lineOffset(src []byte, line int) int {
+	offset := 0
+	for l := 1; l < line; l++ {
+		i := bytes.IndexByte(src[offset:], '\n')
+		if i < 0 {
+			return len(src)
+		}
+		offset += i + 1
+	}
+	return offset
+}
+
+// nodeLine returns the line of the template source node starts on.
+func nodeLine(tree *parse.Tree, node parse.Node) int {
+	location, _ := tree.ErrorContext(node)
+	parts := strings.Split(location, ":")
+	if len(parts) < 3 {
+		return 0
+	}
+	line, _ := strconv.Atoi(parts[len(parts)-2])
+	return line
+}
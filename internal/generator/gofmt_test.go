@@ -0,0 +1,248 @@
+package generator
+
+import (
+	"go/format"
+	"go/token"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+)
+
+func TestFormatGo(t *testing.T) {
+	tests := []struct {
+		name          string
+		src           string
+		module        string
+		expectedValue string
+	}{
+		{
+			name:          "indentation",
+			src:           "package main\n\nfunc main() {\n    if true {\n  println()\n    }\n}",
+			expectedValue: "package main\n\nfunc main() {\n\tif true {\n\t\tprintln()\n\t}\n}\n",
+		},
+		{
+			name: "imports grouped and sorted",
+			src: `package main
+
+import (
+    "github.com/gofiber/fiber/v2"
+    "os"
+    log "github.com/sirupsen/logrus"
+
+    "fmt"
+)
+`,
+			expectedValue: `package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	log "github.com/sirupsen/logrus"
+)
+`,
+		},
+		{
+			name:   "module without a dot is not standard library",
+			module: "myservice",
+			src: `package main
+
+import (
+	"myservice/internal/handler"
+	"github.com/gin-gonic/gin"
+	"myservicetools"
+	"net/http"
+)
+`,
+			expectedValue: `package main
+
+import (
+	"myservicetools"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"myservice/internal/handler"
+)
+`,
+		},
+		{
+			name:          "single import",
+			src:           "package main\nimport \"fmt\"\nfunc main() { fmt.Println() }\n",
+			expectedValue: "package main\n\nimport \"fmt\"\n\nfunc main() { fmt.Println() }\n",
+		},
+		{
+			name: "import block with comments is only formatted",
+			src: `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	// for the config
+	"os"
+)
+`,
+			expectedValue: `package main
+
+import (
+	"github.com/gin-gonic/gin"
+	// for the config
+	"os"
+)
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := formatGo([]byte(tt.src), tt.module)
+			if err != nil {
+				t.Fatalf("formatGo() error = %v", err)
+			}
+			if string(actual) != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", string(actual), tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestRecorder(t *testing.T) {
+	tmpl, err := template.New("main.go.tmpl").Funcs(funcMap).Parse("{{define \"header\"}}// header\n{{end}}package main\n{{template \"header\"}}{{if true}}\n// if\n{{end}}{{range .Items}}// {{.}}\n{{end}}func main() {}\n")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	rec := newRecorder(tmpl)
+	if err := tmpl.Execute(rec, map[string]any{"Items": []string{"a"}}); err != nil {
+		t.Fatalf("Execute() error = %v", err)
+	}
+
+	// Every write except the value of {{.}} is template text.
+	for i, s := range rec.segments {
+		end := rec.buf.Len()
+		if i+1 < len(rec.segments) {
+			end = rec.segments[i+1].start
+		}
+		written := rec.buf.String()[s.start:end]
+		if s.text == nil && written != "a" {
+			t.Fatalf("Text %q was not recognized as the text of a template node. text/template "+
+				"no longer passes TextNode.Text to Write as is, which recorder relies on to report template lines.", written)
+		}
+	}
+
+	for _, tt := range []struct {
+		line          int
+		expectedValue string
+	}{
+		{line: 1, expectedValue: "main.go.tmpl:2"},
+		{line: 2, expectedValue: "main.go.tmpl:1"},
+		{line: 3, expectedValue: "main.go.tmpl:3"},
+		{line: 4, expectedValue: "main.go.tmpl:4"},
+		{line: 5, expectedValue: "main.go.tmpl:5"},
+		{line: 6, expectedValue: "main.go.tmpl:6"},
+	} {
+		actual := rec.locate("templates/api/main.go.tmpl", token.Position{Line: tt.line, Column: 1})
+		if actual != "templates/api/"+tt.expectedValue {
+			t.Errorf("Value not match for line %d\nactual = %v\nexpected = %v", tt.line, actual, "templates/api/"+tt.expectedValue)
+		}
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	src := []byte("package main\n\nfunc main() {\n\tx := \n}\n")
+
+	tests := []struct {
+		name          string
+		line          int
+		expectedValue string
+	}{
+		{name: "middle", line: 4, expectedValue: "     2 | \n     3 | func main() {\n>    4 | \tx := \n     5 | }"},
+		{name: "first line", line: 1, expectedValue: ">    1 | package main\n     2 | \n     3 | func main() {"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := snippet(src, tt.line); actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %q\nexpected = %q", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestRender_InvalidGo(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   fstest.MapFS
+		wantErr string
+	}{
+		{
+			name: "mistake in the template text",
+			files: fstest.MapFS{
+				"templates/api/main.go.tmpl": {Data: []byte("package main\n\n// {{.ProjectName}}\nfunc main() {\n\tx := \n}\n")},
+			},
+			wantErr: "templates/api/main.go.tmpl:6: invalid Go on rendered line 6: expected operand, found '}'\n     4 | func main() {\n     5 | \tx := \n>    6 | }",
+		},
+		{
+			name: "lines removed by an action",
+			files: fstest.MapFS{
+				"templates/api/main.go.tmpl": {Data: []byte("package main\n{{if false}}\n\n\n{{end}}\nfunc main() {\n\tx := \n}\n")},
+			},
+			wantErr: "templates/api/main.go.tmpl:8: invalid Go on rendered line 5",
+		},
+		{
+			name: "mistake in a partial",
+			files: fstest.MapFS{
+				"templates/_partials/handler.tmpl": {Data: []byte("{{define \"handler\"}}\nfunc handle() {\n\treturn nil,\n}\n{{end}}")},
+				"templates/api/main.go.tmpl":       {Data: []byte("package main\n\n{{template \"handler\" .}}\n")},
+			},
+			wantErr: "templates/_partials/handler.tmpl:4: invalid Go on rendered line 6",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ProjectConfig{
+				ProjectName: "invalid",
+				ModuleName:  "github.com/test/invalid",
+				TemplateDir: "templates/api",
+				Templates:   []string{"templates/api/main.go.tmpl"},
+				FS:          tt.files,
+			}
+
+			_, err := config.Render()
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Render() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRender_EmbeddedTemplatesAreFormatted(t *testing.T) {
+	for _, id := range []string{"fiber", "gin"} {
+		t.Run(id, func(t *testing.T) {
+			config := ProjectConfig{
+				ProjectName: "formatted",
+				ModuleName:  "github.com/test/formatted",
+				TemplateDir: "templates/" + id,
+				Templates:   []string{"templates/" + id + "/main.go.tmpl"},
+			}
+
+			files, err := config.Render()
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, f := range files {
+				if !strings.HasSuffix(f.Path, ".go") {
+					continue
+				}
+				formatted, err := format.Source(f.Content)
+				if err != nil {
+					t.Fatalf("format.Source() error = %v", err)
+				}
+				if string(formatted) != string(f.Content) {
+					t.Errorf("%s is not gofmt clean:\n%s", f.Path, f.Content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package generator
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/runner"
+)
+
+// Environment variables every hook gets next to its own Env.
+const (
+	EnvProjectName = "GOAT_PROJECT_NAME"
+	EnvModuleName  = "GOAT_MODULE_NAME"
+	EnvProjectDir  = "GOAT_PROJECT_DIR"
+)
+
+var actionCommands = map[string][]string{
+	manifest.ActionGitInit:    {"git", "init"},
+	manifest.ActionGoModTidy:  {"go", "mod", "tidy"},
+	manifest.ActionGofmt:      {"gofmt", "-l", "-w", "."},
+	manifest.ActionGoGenerate: {"go", "generate", "./..."},
+}
+
+// RunHooks runs hooks in order in dir and stops at the first one that fails
+// or when ctx is done. Hooks whose when condition is false are skipped.
+// A failing command is reported as a *runner.Error.
+func (config ProjectConfig) RunHooks(ctx context.Context, hooks []manifest.Hook, dir string) error {
+	for _, hook := range hooks {
+		if hook.When != "" {
+			ok, err := evaluateCondition(hook.When, config)
+			if err != nil {
+				return fmt.Errorf("hook '%s': %w", hook, err)
+			}
+			if !ok {
+				fmt.Printf("Skipped hook '%s'\n", hook)
+				continue
+			}
+		}
+		if err := config.runHook(ctx, hook, dir); err != nil {
+			return fmt.Errorf("hook '%s': %w", hook, err)
+		}
+	}
+	return nil
+}
+
+func (config ProjectConfig) runHook(ctx context.Context, hook manifest.Hook, dir string) error {
+	workDir, err := hookDir(hook, dir, config)
+	if err != nil {
+		return err
+	}
+	env, err := config.hookEnv(hook)
+	if err != nil {
+		return err
+	}
+	args := hookCommand(hook)
+
+	fmt.Printf("Running hook '%s'...\n", hook)
+	err = runner.Run(ctx, runner.Command{
+		Name:    args[0],
+		Args:    args[1:],
+		Dir:     workDir,
+		Env:     env,
+		Timeout: hook.TimeoutDuration(),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Hook '%s' completed successfully.\n", hook)
+	return nil
+}
+
+// hookCommand returns the program and arguments a hook runs.
+func hookCommand(hook manifest.Hook) []string {
+	switch {
+	case hook.Run != "" && runtime.GOOS == "windows":
+		return []string{"cmd", "/C", hook.Run}
+	case hook.Run != "":
+		return []string{"sh", "-c", hook.Run}
+	case hook.Go != "":
+		return append([]string{"go"}, strings.Fields(hook.Go)...)
+	}
+	return actionCommands[hook.Action]
+}
+
+// hookDir resolves the dir of a hook, which must stay inside dir.
+func hookDir(hook manifest.Hook, dir string, config ProjectConfig) (string, error) {
+	if hook.Dir == "" {
+		return dir, nil
+	}
+	rel, err := renderString(hook.Dir, config)
+	if err != nil {
+		return "", err
+	}
+	rel = filepath.Clean(filepath.FromSlash(rel))
+	if filepath.IsAbs(rel) || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("dir %q escapes %s", hook.Dir, dir)
+	}
+	return filepath.Join(dir, rel), nil
+}
+
+func (config ProjectConfig) hookEnv(hook manifest.Hook) ([]string, error) {
+	projectDir, err := filepath.Abs(config.Dir())
+	if err != nil {
+		return nil, err
+	}
+	env := []string{
+		EnvProjectName + "=" + config.ProjectName,
+		EnvModuleName + "=" + config.ModuleName,
+		EnvProjectDir + "=" + projectDir,
+	}
+	env = append(env, config.goEnv()...)
+	for _, name := range sortedKeys(hook.Env) {
+		value, err := renderString(hook.Env[name], config)
+		if err != nil {
+			return nil, fmt.Errorf("env %s: %w", name, err)
+		}
+		env = append(env, name+"="+value)
+	}
+	return env, nil
+}
@@ -0,0 +1,118 @@
+package generator
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/smilepakawat/goat/internal/manifest"
+)
+
+func TestRunHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks in this test use sh")
+	}
+
+	tests := []struct {
+		name          string
+		hooks         []manifest.Hook
+		expectedValue string
+		wantErr       string
+	}{
+		{
+			name: "environment and dir",
+			hooks: []manifest.Hook{
+				{Run: `echo "$GOAT_PROJECT_NAME $GOAT_MODULE_NAME $SERVICE $(basename "$PWD")" > ../out.txt`, Dir: "sub", Env: map[string]string{"SERVICE": "{{.ProjectName | upper}}"}},
+			},
+			expectedValue: "hooked github.com/test/hooked HOOKED sub\n",
+		},
+		{
+			name: "in order, skipping false conditions",
+			hooks: []manifest.Hook{
+				{Run: "echo first >> out.txt"},
+				{Run: "echo docker >> out.txt", When: ".Docker"},
+				{Run: "echo last >> out.txt", When: "not .Docker"},
+			},
+			expectedValue: "first\nlast\n",
+		},
+		{
+			name: "stops at the first failure",
+			hooks: []manifest.Hook{
+				{Name: "broken", Run: "echo oops; exit 3"},
+				{Run: "echo never > out.txt"},
+			},
+			wantErr: "hook 'broken': 'sh -c echo oops; exit 3' failed with exit code 3",
+		},
+		{
+			name:    "timeout",
+			hooks:   []manifest.Hook{{Run: "sleep 5", Timeout: "100ms"}},
+			wantErr: "timed out after 100ms",
+		},
+		{
+			name:    "dir outside of the directory",
+			hooks:   []manifest.Hook{{Run: "true", Dir: "../.."}},
+			wantErr: "escapes",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			if err := os.Mkdir(filepath.Join(dir, "sub"), 0755); err != nil {
+				t.Fatal(err)
+			}
+			config := ProjectConfig{
+				ProjectName: "hooked",
+				ModuleName:  "github.com/test/hooked",
+				Variables:   map[string]any{"Docker": false},
+				OutputDir:   dir,
+			}
+
+			err := config.RunHooks(context.Background(), tt.hooks, dir)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("RunHooks() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				if _, err := os.Stat(filepath.Join(dir, "out.txt")); err == nil {
+					t.Error("Hooks after the failing one must not run")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("RunHooks() error = %v", err)
+			}
+			content, err := os.ReadFile(filepath.Join(dir, "out.txt"))
+			if err != nil {
+				t.Fatalf("Failed to read hook output: %v", err)
+			}
+			if string(content) != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", string(content), tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestHookCommand(t *testing.T) {
+	tests := []struct {
+		name          string
+		hook          manifest.Hook
+		expectedValue []string
+	}{
+		{name: "go subcommand", hook: manifest.Hook{Go: "generate ./..."}, expectedValue: []string{"go", "generate", "./..."}},
+		{name: "go mod tidy", hook: manifest.Hook{Action: manifest.ActionGoModTidy}, expectedValue: []string{"go", "mod", "tidy"}},
+		{name: "gofmt", hook: manifest.Hook{Action: manifest.ActionGofmt}, expectedValue: []string{"gofmt", "-l", "-w", "."}},
+		{name: "git init", hook: manifest.Hook{Action: manifest.ActionGitInit}, expectedValue: []string{"git", "init"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual := hookCommand(tt.hook)
+			if strings.Join(actual, " ") != strings.Join(tt.expectedValue, " ") {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
@@ -1,115 +1,487 @@
 package generator
 
 import (
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io/fs"
 	"os"
+	"path"
 	"path/filepath"
-	"regexp"
 	"slices"
+	"strings"
 	"text/template"
 
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/version"
 	"github.com/smilepakawat/goat/pkg"
 )
 
 type ProjectConfig struct {
-	ProjectName string
-	ModuleName  string
-	Templates   []string
+	ProjectName  string
+	ModuleName   string
+	TemplateDir  string
+	LayerDirs    []string
+	Templates    []string
+	Destinations map[string]string
+	Conditions   map[string]string
+	KeepEmpty    map[string]bool
+	Variables    map[string]any
+	Manifest     *manifest.Manifest
+	FS           fs.FS
+	Source       lock.Template
+
+	// OutputDir is where the project is written, ProjectName when empty.
+	OutputDir string
+	// Conflict allows generating into an existing directory and decides what
+	// happens to files that already exist there. Prompt is asked per file for
+	// ConflictPrompt and reads the answer from in, which is shared by all
+	// prompts of a run so answers piped to goat are not lost in a buffer.
+	Conflict Conflict
+	Prompt   func(in *bufio.Reader, path string) (Conflict, error)
+
+	KeepOnFailure bool
+	// Offline makes the go commands goat runs use the module cache only.
+	Offline bool
+}
+
+// partialsDir holds files with {{define}} blocks that are parsed into every
+// template: the one below the templates root is shared by all templates, the
+// one inside a template directory only by that template and wins on conflicts.
+const partialsDir = "_partials"
+
+// dotPrefix marks files and directories whose name starts with a dot in the
+// generated project, e.g. dot_github/workflows/ci.yml becomes .github/workflows/ci.yml.
+// Templates do not ship real dotfiles, which would apply to this repository
+// itself: a template's .gitignore would hide files from git here.
+const dotPrefix = "dot_"
+
+// Layer is one template of a layered template, see manifest.Manifest.Extends.
+// An Overlay layer is added on top of the selected template and only
+// contributes files and variables, see manifest.Manifest.WithOverlays.
+type Layer struct {
+	Dir      string
+	Manifest manifest.Manifest
+	Overlay  bool
+}
+
+// ApplyManifest points the config at the template in dir, taking the file list
+// and destinations from its manifest. Files may point into sibling templates
+// (e.g. ../base/dot_gitignore.tmpl) but never outside of the templates root.
+// Directories are expanded to every file below them.
+func (config *ProjectConfig) ApplyManifest(dir string, m manifest.Manifest) error {
+	return config.ApplyLayers(Layer{Dir: dir, Manifest: m})
+}
+
+// ApplyLayers combines templates layered on each other, earliest first. Files
+// of later layers replace files of earlier ones with the same destination and
+// variables are merged, see manifest.Merge. The template directory and the
+// manifest's name and version are those of the last layer that is not an
+// overlay.
+func (config *ProjectConfig) ApplyLayers(layers ...Layer) error {
+	selected := slices.IndexFunc(layers, func(l Layer) bool { return l.Overlay })
+	if selected < 0 {
+		selected = len(layers)
+	}
+	selected--
+	if selected < 0 {
+		return errors.New("no template to apply")
+	}
+
+	var templates []string
+	destinations := make(map[string]string)
+	conditions := make(map[string]string)
+	keepEmpty := make(map[string]bool)
+	dirs := make([]string, 0, len(layers))
+	var manifests, overlays []manifest.Manifest
+	for i, layer := range layers {
+		m := layer.Manifest
+		root, _, _ := strings.Cut(layer.Dir, "/")
+		for _, f := range m.Files {
+			resolved := path.Join(layer.Dir, f.Src)
+			if path.IsAbs(f.Src) || !strings.HasPrefix(resolved, root+"/") {
+				return fmt.Errorf("template %s: file %q is outside of %s", m.Name, f.Src, root)
+			}
+
+			files, err := config.expandDir(resolved)
+			if err != nil {
+				return fmt.Errorf("template %s: %w", m.Name, err)
+			}
+			if files != nil && f.Dest != "" {
+				return fmt.Errorf("template %s: directory %q cannot have a dest", m.Name, f.Src)
+			}
+			if files == nil {
+				files = []string{resolved}
+			}
+
+			for _, file := range files {
+				templates = append(templates, file)
+				if f.Dest != "" {
+					destinations[file] = f.Dest
+				}
+				if f.When != "" {
+					conditions[file] = f.When
+				}
+				if f.KeepEmpty {
+					keepEmpty[file] = true
+				}
+			}
+		}
+		if i == selected {
+			continue
+		}
+		dirs = append(dirs, layer.Dir)
+		if layer.Overlay {
+			overlays = append(overlays, m)
+		} else {
+			manifests = append(manifests, m)
+		}
+	}
+
+	merged := manifest.Merge(append(manifests, layers[selected].Manifest)...).WithOverlays(overlays...)
+	config.TemplateDir = layers[selected].Dir
+	config.LayerDirs = dirs
+	config.Templates = templates
+	config.Destinations = destinations
+	config.Conditions = conditions
+	config.KeepEmpty = keepEmpty
+	config.Manifest = &merged
+	return nil
+}
+
+// expandDir lists the files below p, or returns nil when p is not a directory.
+func (config *ProjectConfig) expandDir(p string) ([]string, error) {
+	info, err := fs.Stat(config.templatesFS(), p)
+	if err != nil || !info.IsDir() {
+		return nil, nil
+	}
+
+	files := []string{}
+	err = fs.WalkDir(config.templatesFS(), p, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && d.Name() == partialsDir {
+			return fs.SkipDir
+		}
+		if !d.IsDir() {
+			files = append(files, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %s: %w", p, err)
+	}
+	return files, nil
+}
+
+// Validate reports every problem with the config without changing it.
+func (config ProjectConfig) Validate() error {
+	_, err := config.Resolve()
+	return err
+}
+
+// Resolve validates the config and returns a copy ready to render: Variables
+// hold the answers typed as the manifest declares them and the defaults of
+// unanswered variables.
+func (config ProjectConfig) Resolve() (ProjectConfig, error) {
+	var errs []error
+	if config.ProjectName == "" {
+		errs = append(errs, errors.New("project name is required"))
+	}
+	if config.ModuleName == "" {
+		errs = append(errs, errors.New("module name is required"))
+	}
+	if config.Manifest != nil {
+		if err := config.Manifest.CheckGoatVersion(version.Version); err != nil {
+			errs = append(errs, err)
+		}
+		variables, err := config.Manifest.ResolveVariables(config.Variables)
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			config.Variables = variables
+		}
+	}
+	return config, errors.Join(errs...)
 }
 
-type InvisibleFiles struct {
-	name []string
+// Dir is the directory the project is generated into.
+func (config ProjectConfig) Dir() string {
+	if config.OutputDir != "" {
+		return config.OutputDir
+	}
+	return config.ProjectName
 }
 
-var invisibleFiles = InvisibleFiles{
-	name: []string{
-		"gitignore",
-	},
+func (config ProjectConfig) templatesFS() fs.FS {
+	if config.FS == nil {
+		return pkg.Templates
+	}
+	return config.FS
 }
 
+func (config ProjectConfig) templateData() map[string]any {
+	data := make(map[string]any, len(config.Variables)+2)
+	for name, value := range config.Variables {
+		data[name] = value
+	}
+	data["ProjectName"] = config.ProjectName
+	data["ModuleName"] = config.ModuleName
+	return data
+}
+
+// GenerateProject renders every template into a staging directory next to
+// the project and only moves it into place once all files were written, so a
+// failed run leaves nothing behind (unless KeepOnFailure is set).
+// An existing directory is only written to when a Conflict strategy is set.
 func (config ProjectConfig) GenerateProject() error {
+	config, err := config.Resolve()
+	if err != nil {
+		return err
+	}
+
+	dir := config.Dir()
 	fmt.Printf("Creating project '%s' with module '%s'...\n", config.ProjectName, config.ModuleName)
 
-	if err := os.Mkdir(config.ProjectName, 0755); err != nil {
-		return fmt.Errorf("failed to create project directory %s: %w", config.ProjectName, err)
+	if info, err := os.Stat(dir); err == nil {
+		if config.Conflict == "" || !info.IsDir() {
+			return fmt.Errorf("failed to create project directory %s: %w", dir, fs.ErrExist)
+		}
+		summary, err := config.generateInto(dir)
+		summary.Print(os.Stdout)
+		return err
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to create project directory %s: %w", dir, err)
 	}
-	fmt.Printf("Created directory: %s\n", config.ProjectName)
 
-	templateFiles := mapTemplates(config.Templates, config.ProjectName)
+	staging, err := os.MkdirTemp(filepath.Dir(dir), "."+filepath.Base(dir)+".goat-*")
+	if err != nil {
+		return fmt.Errorf("failed to create staging directory: %w", err)
+	}
 
-	for tmplPath, outputPath := range templateFiles {
-		if err := processTemplate(tmplPath, outputPath, config); err != nil {
-			return fmt.Errorf("failed to process template %s: %w", tmplPath, err)
+	if err := config.render(staging); err != nil {
+		if config.KeepOnFailure {
+			if moveErr := moveIntoPlace(staging, dir); moveErr == nil {
+				return fmt.Errorf("%w (partial project kept in %s)", err, dir)
+			}
 		}
-		fmt.Printf("Created file: %s from template %s\n", outputPath, tmplPath)
+		os.RemoveAll(staging)
+		return err
+	}
+
+	if err := moveIntoPlace(staging, dir); err != nil {
+		os.RemoveAll(staging)
+		return err
 	}
+	fmt.Printf("Created directory: %s\n", dir)
 
 	return nil
 }
 
-func mapTemplates(templates []string, projectName string) map[string]string {
-	res := make(map[string]string)
-	reg := regexp.MustCompile(`([^/]+?)\.tmpl$`)
-	for _, t := range templates {
-		matches := reg.FindStringSubmatch(t)
-		if len(matches) != 0 {
-			destFile := buildDestinationFile(matches[1])
-			res[t] = filepath.Join(projectName, destFile)
+// render writes the output of Render into projectDir.
+func (config ProjectConfig) render(projectDir string) error {
+	files, err := config.Render()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		rel := filepath.FromSlash(f.Path)
+		if err := writeFile(filepath.Join(projectDir, rel), f.Content); err != nil {
+			return err
+		}
+		if f.Template == "" {
+			fmt.Printf("Created file: %s\n", filepath.Join(config.Dir(), rel))
+		} else {
+			fmt.Printf("Created file: %s from template %s\n", filepath.Join(config.Dir(), rel), f.Template)
 		}
 	}
-	return res
+	return nil
 }
 
-func buildDestinationFile(name string) string {
-	if isInvisibleFile(name) {
-		return "." + name
-	} else {
-		return name
+func moveIntoPlace(staging, projectDir string) error {
+	if err := os.Chmod(staging, 0755); err != nil {
+		return fmt.Errorf("failed to set permissions of %s: %w", projectDir, err)
+	}
+	if _, err := os.Lstat(projectDir); err == nil {
+		return fmt.Errorf("failed to create project directory %s: %w", projectDir, fs.ErrExist)
+	}
+	if err := os.Rename(staging, projectDir); err != nil {
+		return fmt.Errorf("failed to create project directory %s: %w", projectDir, err)
 	}
+	return nil
 }
 
-func isInvisibleFile(name string) bool {
-	return slices.Contains(invisibleFiles.name, name)
+func mapTemplates(config ProjectConfig, projectName string) (map[string]string, error) {
+	res := make(map[string]string)
+	owners := make(map[string]string)
+	for _, t := range config.Templates {
+		if when, ok := config.Conditions[t]; ok {
+			include, err := evaluateCondition(when, config)
+			if err != nil {
+				return nil, fmt.Errorf("failed to evaluate when of template %s: %w", t, err)
+			}
+			if !include {
+				continue
+			}
+		}
+
+		dest, ok := config.Destinations[t]
+		if !ok {
+			var isTemplate bool
+			dest, isTemplate = strings.CutSuffix(config.relativeTemplatePath(t), ".tmpl")
+			if !isTemplate || path.Base(dest) == "" {
+				continue
+			}
+		}
+
+		rendered, err := renderPath(dest, !ok, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to build destination for template %s: %w", t, err)
+		}
+		// Templates are ordered by layer, a later layer overrides the file.
+		if previous, ok := owners[rendered]; ok {
+			delete(res, previous)
+		}
+		owners[rendered] = t
+		res[t] = filepath.Join(projectName, filepath.FromSlash(rendered))
+	}
+	return res, nil
 }
 
-func processTemplate(templatePath, outputPath string, config ProjectConfig) error {
-	tmpl, err := loadAndParseTemplate(templatePath)
+// renderPath executes a destination path such as cmd/{{.ProjectName}}/main.go
+// with the template data and makes sure the result stays inside the project.
+// With dotFiles, dot_ segments are renamed first, so a segment such as dot_.
+// cannot turn into .. after the check.
+func renderPath(p string, dotFiles bool, config ProjectConfig) (string, error) {
+	p, err := renderString(p, config)
 	if err != nil {
-		return fmt.Errorf("failed to load template %s: %w", templatePath, err)
+		return "", err
 	}
 
-	if err := executeTemplateToFile(tmpl, outputPath, config); err != nil {
-		return fmt.Errorf("failed to execute template %s: %w", templatePath, err)
+	p = strings.ReplaceAll(p, "\\", "/")
+	if dotFiles {
+		p = buildDestinationFile(p)
 	}
-
-	return nil
+	clean := path.Clean(p)
+	if path.IsAbs(p) || filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return "", fmt.Errorf("path %q must be relative to the project directory", p)
+	}
+	if clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+		return "", fmt.Errorf("path %q escapes the project directory", p)
+	}
+	return clean, nil
 }
 
-func loadAndParseTemplate(templatePath string) (*template.Template, error) {
-	tmplContent, err := pkg.Templates.ReadFile(templatePath)
+// renderString renders s with the template data when it contains an action.
+func renderString(s string, config ProjectConfig) (string, error) {
+	if !strings.Contains(s, "{{") {
+		return s, nil
+	}
+	tmpl, err := template.New(s).Funcs(funcMap).Option("missingkey=error").Parse(s)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read template file: %w", err)
+		return "", fmt.Errorf("failed to parse %q: %w", s, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, config.templateData()); err != nil {
+		return "", fmt.Errorf("failed to render %q: %w", s, err)
 	}
+	return sb.String(), nil
+}
 
-	tmpl, err := template.New(filepath.Base(templatePath)).Parse(string(tmplContent))
+// evaluateCondition reports whether the template pipeline when, e.g.
+// `ne .Database "none"`, is true for the template data.
+func evaluateCondition(when string, config ProjectConfig) (bool, error) {
+	tmpl, err := template.New("when").Funcs(funcMap).Option("missingkey=error").Parse("{{if " + when + "}}true{{end}}")
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse template: %w", err)
+		return false, fmt.Errorf("failed to parse %q: %w", when, err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, config.templateData()); err != nil {
+		return false, fmt.Errorf("failed to evaluate %q: %w", when, err)
 	}
+	return sb.String() == "true", nil
+}
 
-	return tmpl, nil
+// skipEmpty reports whether a rendered template is left out of the project
+// because it only contains whitespace.
+func (config ProjectConfig) skipEmpty(templatePath string, content []byte) bool {
+	return !config.KeepEmpty[templatePath] && len(bytes.TrimSpace(content)) == 0
+}
+
+// relativeTemplatePath returns the path of t inside its template, i.e. without
+// the template directory, or without templates/<id>/ for files that live in
+// another template such as base.
+func (config ProjectConfig) relativeTemplatePath(t string) string {
+	for _, dir := range slices.Concat([]string{config.TemplateDir}, config.LayerDirs) {
+		if dir == "" {
+			continue
+		}
+		if rel, ok := strings.CutPrefix(t, dir+"/"); ok {
+			return rel
+		}
+	}
+	if parts := strings.SplitN(t, "/", 3); len(parts) == 3 {
+		return parts[2]
+	}
+	return path.Base(t)
 }
 
-func executeTemplateToFile(tmpl *template.Template, outputPath string, config ProjectConfig) error {
+func buildDestinationFile(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		if isInvisibleFile(segment) {
+			segments[i] = "." + strings.TrimPrefix(segment, dotPrefix)
+		}
+	}
+	return strings.Join(segments, "/")
+}
 
-	outputFile, err := os.Create(outputPath)
+func isInvisibleFile(name string) bool {
+	return len(name) > len(dotPrefix) && strings.HasPrefix(name, dotPrefix)
+}
+
+func loadAndParseTemplate(fsys fs.FS, templatePath string, partialDirs ...string) (*template.Template, error) {
+	tmplContent, err := fs.ReadFile(fsys, templatePath)
 	if err != nil {
-		return fmt.Errorf("failed to create output file: %w", err)
+		return nil, fmt.Errorf("failed to read template file: %w", err)
 	}
-	defer outputFile.Close()
 
-	if err := tmpl.Execute(outputFile, config); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+	tmpl := template.New(filepath.Base(templatePath)).Funcs(funcMap)
+	for _, dir := range partialDirs {
+		partials, err := fs.Glob(fsys, path.Join(dir, "*.tmpl"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list partials: %w", err)
+		}
+		for _, partial := range partials {
+			content, err := fs.ReadFile(fsys, partial)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read partial %s: %w", partial, err)
+			}
+			if _, err := tmpl.New(partial).Parse(string(content)); err != nil {
+				return nil, fmt.Errorf("failed to parse partial %s: %w", partial, err)
+			}
+		}
 	}
 
-	return nil
+	if _, err := tmpl.Parse(string(tmplContent)); err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+// partialDirs returns the partial directories for templatePath, shared ones first.
+func (config ProjectConfig) partialDirs(templatePath string) []string {
+	root, _, _ := strings.Cut(templatePath, "/")
+	dirs := []string{path.Join(root, partialsDir)}
+	for _, dir := range slices.Concat(config.LayerDirs, []string{config.TemplateDir}) {
+		if dir != "" {
+			dirs = append(dirs, path.Join(dir, partialsDir))
+		}
+	}
+	return dirs
 }
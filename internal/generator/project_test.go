@@ -1,12 +1,20 @@
 package generator
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 	"testing"
+	"testing/fstest"
 	"text/template"
+
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/manifest"
+	"github.com/smilepakawat/goat/internal/version"
+	"github.com/smilepakawat/goat/pkg"
 )
 
 func TestGenerateProject(t *testing.T) {
@@ -188,122 +196,48 @@ func TestGenerateProject_DirectoryPermissions(t *testing.T) {
 	}
 }
 
-func TestProcessTemplate(t *testing.T) {
-	tempDir := t.TempDir()
-
+func TestRenderTemplate(t *testing.T) {
 	tests := []struct {
 		name         string
 		templatePath string
-		outputPath   string
-		config       ProjectConfig
 		wantErr      bool
 	}{
 		{
 			name:         "nonexistent template file",
 			templatePath: "nonexistent.tmpl",
-			outputPath:   filepath.Join(tempDir, "test_output.go"),
-			config: ProjectConfig{
-				ProjectName: "testproject",
-				ModuleName:  "github.com/test/testproject",
-			},
-			wantErr: true,
-		},
-		{
-			name:         "invalid output directory",
-			templatePath: "templates/fiber/main.go.tmpl",
-			outputPath:   "/nonexistent/directory/test_output.go",
-			config: ProjectConfig{
-				ProjectName: "testproject",
-				ModuleName:  "github.com/test/testproject",
-			},
-			wantErr: true,
+			wantErr:      true,
 		},
 		{
 			name:         "empty template path",
 			templatePath: "",
-			outputPath:   filepath.Join(tempDir, "test_output.go"),
-			config: ProjectConfig{
-				ProjectName: "testproject",
-				ModuleName:  "github.com/test/testproject",
-			},
-			wantErr: true,
-		},
-		{
-			name:         "empty output path",
-			templatePath: "templates/fiber/main.go.tmpl",
-			outputPath:   "",
-			config: ProjectConfig{
-				ProjectName: "testproject",
-				ModuleName:  "github.com/test/testproject",
-			},
-			wantErr: true,
+			wantErr:      true,
 		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			templatePath := tt.templatePath
-
-			err := processTemplate(templatePath, tt.outputPath, tt.config)
-
-			// Check error expectation
-			if (err != nil) != tt.wantErr {
-				t.Errorf("processTemplate() error = %v, wantErr %v", err, tt.wantErr)
-				return
-			}
-		})
-	}
-}
-
-// Test to verify file creation in subtest pattern
-// Test for testing actual template files (integration test)
-func TestProcessTemplate_RealTemplates(t *testing.T) {
-	tempDir := t.TempDir()
-
-	config := ProjectConfig{
-		ProjectName: "testproject",
-		ModuleName:  "github.com/test/testproject",
-	}
-
-	tests := []struct {
-		name         string
-		templatePath string
-		outputPath   string
-		wantErr      bool
-	}{
 		{
-			name:         "process go.mod template",
+			name:         "go.mod template",
 			templatePath: "templates/fiber/go.mod.tmpl",
-			outputPath:   filepath.Join(tempDir, "go.mod"),
-			wantErr:      false,
 		},
 		{
-			name:         "process main.go template",
+			name:         "main.go template",
 			templatePath: "templates/fiber/main.go.tmpl",
-			outputPath:   filepath.Join(tempDir, "main.go"),
-			wantErr:      false,
 		},
 		{
-			name:         "process .gitignore template",
-			templatePath: "templates/base/gitignore.tmpl",
-			outputPath:   filepath.Join(tempDir, ".gitignore"),
-			wantErr:      false,
+			name:         ".gitignore template",
+			templatePath: "templates/base/dot_gitignore.tmpl",
 		},
 	}
 
+	config := ProjectConfig{
+		ProjectName: "testproject",
+		ModuleName:  "github.com/test/testproject",
+	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := processTemplate(tt.templatePath, tt.outputPath, config)
+			content, err := renderTemplate(tt.templatePath, config)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("processTemplate() error = %v, wantErr %v", err, tt.wantErr)
-				return
+				t.Fatalf("renderTemplate() error = %v, wantErr %v", err, tt.wantErr)
 			}
-
-			if !tt.wantErr {
-				// Verify file was created
-				if _, err := os.Stat(tt.outputPath); os.IsNotExist(err) {
-					t.Errorf("Output file %s was not created", tt.outputPath)
-				}
+			if !tt.wantErr && len(content) == 0 {
+				t.Errorf("renderTemplate() rendered %s empty", tt.templatePath)
 			}
 		})
 	}
@@ -320,14 +254,25 @@ func TestMapTemplates(t *testing.T) {
 		{
 			name: "success generate map of templates",
 			templates: []string{
-				"templates/base/gitignore.tmpl",
+				"templates/base/dot_gitignore.tmpl",
 				"templates/fiber/main.go.tmpl",
 				"templates/fiber/go.mod.tmpl",
 			},
 			expectedValue: map[string]string{
-				"templates/base/gitignore.tmpl": filepath.Join(tempDir, ".gitignore"),
-				"templates/fiber/main.go.tmpl":  filepath.Join(tempDir, "main.go"),
-				"templates/fiber/go.mod.tmpl":   filepath.Join(tempDir, "go.mod"),
+				"templates/base/dot_gitignore.tmpl": filepath.Join(tempDir, ".gitignore"),
+				"templates/fiber/main.go.tmpl":      filepath.Join(tempDir, "main.go"),
+				"templates/fiber/go.mod.tmpl":       filepath.Join(tempDir, "go.mod"),
+			},
+		},
+		{
+			name: "nested template files keep their directories",
+			templates: []string{
+				"templates/api/cmd/server/main.go.tmpl",
+				"templates/api/internal/handler/health.go.tmpl",
+			},
+			expectedValue: map[string]string{
+				"templates/api/cmd/server/main.go.tmpl":         filepath.Join(tempDir, "cmd", "server", "main.go"),
+				"templates/api/internal/handler/health.go.tmpl": filepath.Join(tempDir, "internal", "handler", "health.go"),
 			},
 		},
 		{
@@ -346,7 +291,10 @@ func TestMapTemplates(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			actual := mapTemplates(tt.templates, tempDir)
+			actual, err := mapTemplates(ProjectConfig{Templates: tt.templates}, tempDir)
+			if err != nil {
+				t.Fatalf("mapTemplates() error = %v", err)
+			}
 			if !reflect.DeepEqual(actual, tt.expectedValue) {
 				t.Errorf("Value not match\nactual = %s\nexpected = %s", actual, tt.expectedValue)
 			}
@@ -354,6 +302,404 @@ func TestMapTemplates(t *testing.T) {
 	}
 }
 
+func TestMapTemplates_TemplateDir(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := ProjectConfig{
+		TemplateDir: "templates/company/api",
+		Templates: []string{
+			"templates/base/dot_gitignore.tmpl",
+			"templates/company/api/cmd/server/main.go.tmpl",
+			"templates/company/api/internal/dot_gitignore.tmpl",
+			"templates/company/api/dot_github/workflows/ci.yml.tmpl",
+			"templates/company/api/doc.go.tmpl",
+		},
+		Destinations: map[string]string{
+			"templates/company/api/doc.go.tmpl": "internal/doc/doc.go",
+		},
+	}
+	expected := map[string]string{
+		"templates/base/dot_gitignore.tmpl":                      filepath.Join(tempDir, ".gitignore"),
+		"templates/company/api/cmd/server/main.go.tmpl":          filepath.Join(tempDir, "cmd", "server", "main.go"),
+		"templates/company/api/internal/dot_gitignore.tmpl":      filepath.Join(tempDir, "internal", ".gitignore"),
+		"templates/company/api/dot_github/workflows/ci.yml.tmpl": filepath.Join(tempDir, ".github", "workflows", "ci.yml"),
+		"templates/company/api/doc.go.tmpl":                      filepath.Join(tempDir, "internal", "doc", "doc.go"),
+	}
+
+	actual, err := mapTemplates(config, tempDir)
+	if err != nil {
+		t.Fatalf("mapTemplates() error = %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Value not match\nactual = %s\nexpected = %s", actual, expected)
+	}
+}
+
+func TestMapTemplates_TemplatedPaths(t *testing.T) {
+	tempDir := t.TempDir()
+
+	config := ProjectConfig{
+		ProjectName: "api",
+		TemplateDir: "templates/api",
+		Templates: []string{
+			"templates/api/cmd/{{.ProjectName}}/main.go.tmpl",
+			"templates/api/repo.go.tmpl",
+		},
+		Destinations: map[string]string{
+			"templates/api/repo.go.tmpl": "internal/{{.ResourceName | lower}}/repo.go",
+		},
+		Variables: map[string]any{"ResourceName": "User"},
+	}
+	expected := map[string]string{
+		"templates/api/cmd/{{.ProjectName}}/main.go.tmpl": filepath.Join(tempDir, "cmd", "api", "main.go"),
+		"templates/api/repo.go.tmpl":                      filepath.Join(tempDir, "internal", "user", "repo.go"),
+	}
+
+	actual, err := mapTemplates(config, tempDir)
+	if err != nil {
+		t.Fatalf("mapTemplates() error = %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Value not match\nactual = %s\nexpected = %s", actual, expected)
+	}
+}
+
+func TestMapTemplates_Conditions(t *testing.T) {
+	tests := []struct {
+		name          string
+		variables     map[string]any
+		expectedValue []string
+		wantErr       bool
+	}{
+		{
+			name:          "all conditions false",
+			variables:     map[string]any{"Docker": false, "Database": "none"},
+			expectedValue: []string{"templates/api/main.go.tmpl"},
+		},
+		{
+			name:          "all conditions true",
+			variables:     map[string]any{"Docker": true, "Database": "postgres"},
+			expectedValue: []string{"templates/api/Dockerfile.tmpl", "templates/api/internal/db/db.go.tmpl", "templates/api/main.go.tmpl"},
+		},
+		{
+			name:      "unknown variable",
+			variables: map[string]any{"Database": "none"},
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ProjectConfig{
+				ProjectName: "api",
+				TemplateDir: "templates/api",
+				Templates:   []string{"templates/api/main.go.tmpl", "templates/api/Dockerfile.tmpl", "templates/api/internal/db/db.go.tmpl"},
+				Conditions: map[string]string{
+					"templates/api/Dockerfile.tmpl":        ".Docker",
+					"templates/api/internal/db/db.go.tmpl": `ne .Database "none"`,
+				},
+				Variables: tt.variables,
+			}
+
+			actual, err := mapTemplates(config, "")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mapTemplates() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var templates []string
+			for tmpl := range actual {
+				templates = append(templates, tmpl)
+			}
+			sort.Strings(templates)
+			if !tt.wantErr && !reflect.DeepEqual(templates, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", templates, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestApplyManifest_Directories(t *testing.T) {
+	config := ProjectConfig{
+		FS: fstest.MapFS{
+			"templates/api/main.go.tmpl":              {Data: []byte("package main")},
+			"templates/api/internal/db/db.go.tmpl":    {Data: []byte("package db")},
+			"templates/api/internal/db/migrate/1.sql": {Data: []byte("")},
+		},
+	}
+	m := manifest.Manifest{
+		Name: "API",
+		Files: []manifest.File{
+			{Src: "main.go.tmpl"},
+			{Src: "internal/db", When: ".Database", KeepEmpty: true},
+		},
+	}
+
+	if err := config.ApplyManifest("templates/api", m); err != nil {
+		t.Fatalf("ApplyManifest() error = %v", err)
+	}
+
+	expectedTemplates := []string{"templates/api/main.go.tmpl", "templates/api/internal/db/db.go.tmpl", "templates/api/internal/db/migrate/1.sql"}
+	if !reflect.DeepEqual(config.Templates, expectedTemplates) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", config.Templates, expectedTemplates)
+	}
+	expectedConditions := map[string]string{
+		"templates/api/internal/db/db.go.tmpl":    ".Database",
+		"templates/api/internal/db/migrate/1.sql": ".Database",
+	}
+	if !reflect.DeepEqual(config.Conditions, expectedConditions) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", config.Conditions, expectedConditions)
+	}
+	if !config.KeepEmpty["templates/api/internal/db/db.go.tmpl"] || config.KeepEmpty["templates/api/main.go.tmpl"] {
+		t.Errorf("Unexpected keepEmpty: %v", config.KeepEmpty)
+	}
+
+	m.Files[1].Dest = "db"
+	if err := config.ApplyManifest("templates/api", m); err == nil {
+		t.Error("ApplyManifest() should refuse a dest for a directory")
+	}
+}
+
+func TestMapTemplates_EscapingPaths(t *testing.T) {
+	tests := []struct {
+		name string
+		dest string
+		vars map[string]any
+	}{
+		{name: "parent directory", dest: "../outside.go"},
+		{name: "absolute path", dest: "/etc/passwd"},
+		{name: "variable escapes", dest: "{{.Dir}}/main.go", vars: map[string]any{"Dir": "../.."}},
+		{name: "variable is absolute", dest: "{{.Dir}}/main.go", vars: map[string]any{"Dir": "/tmp"}},
+		{name: "renders to project root", dest: "{{.Dir}}", vars: map[string]any{"Dir": ""}},
+		{name: "missing variable", dest: "{{.Missing}}/main.go"},
+		{name: "invalid path template", dest: "{{.Dir/main.go"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ProjectConfig{
+				Templates:    []string{"templates/api/main.go.tmpl"},
+				Destinations: map[string]string{"templates/api/main.go.tmpl": tt.dest},
+				Variables:    tt.vars,
+			}
+			if _, err := mapTemplates(config, t.TempDir()); err == nil {
+				t.Errorf("mapTemplates() should refuse destination %q", tt.dest)
+			}
+		})
+	}
+}
+
+func TestMapTemplates_DotPrefixEscapingPaths(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		vars     map[string]any
+	}{
+		{name: "variable renamed to parent", template: "templates/api/{{.Name}}/evil.tmpl", vars: map[string]any{"Name": "dot_."}},
+		{name: "segments renamed to parents", template: "templates/api/dot_./dot_./evil.tmpl"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := ProjectConfig{
+				TemplateDir: "templates/api",
+				Templates:   []string{tt.template},
+				Variables:   tt.vars,
+			}
+			if actual, err := mapTemplates(config, ""); err == nil {
+				t.Errorf("mapTemplates() should refuse %q, got %v", tt.template, actual)
+			}
+		})
+	}
+}
+
+func TestGenerateProject_NestedDirectories(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "nestedproject",
+		ModuleName:  "github.com/test/nestedproject",
+		TemplateDir: "templates/test/nested",
+		Templates: []string{
+			"templates/test/nested/internal/handler/health.go.tmpl",
+		},
+	}
+	defer os.RemoveAll(config.ProjectName)
+
+	if err := config.GenerateProject(); err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(config.ProjectName, "internal", "handler", "health.go"))
+	if err != nil {
+		t.Fatalf("Expected nested file to be created: %v", err)
+	}
+	if !strings.Contains(string(content), "nestedproject is up") {
+		t.Errorf("Generated file should contain the project name, got:\n%s", content)
+	}
+}
+
+func TestGenerateProject_CustomFS(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "fsproject",
+		ModuleName:  "github.com/test/fsproject",
+		Templates:   []string{"templates/local/main.go.tmpl"},
+		FS: fstest.MapFS{
+			"templates/local/main.go.tmpl": {Data: []byte("package main // {{.ModuleName}}")},
+		},
+	}
+	defer os.RemoveAll(config.ProjectName)
+
+	if err := config.GenerateProject(); err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(config.ProjectName, "main.go"))
+	if err != nil {
+		t.Fatalf("Failed to read generated file: %v", err)
+	}
+	if string(content) != "package main // github.com/test/fsproject\n" {
+		t.Errorf("Unexpected content: %s", content)
+	}
+}
+
+func TestGenerateProject_RecordsRemoteSource(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "lockproject",
+		ModuleName:  "github.com/test/lockproject",
+		Templates:   []string{"templates/fiber/main.go.tmpl"},
+		Source: lock.Template{
+			ID:     "fiber",
+			Source: "git+file:///srv/templates.git//fiber@v1.0.0",
+			Commit: "0123456789abcdef0123456789abcdef01234567",
+		},
+	}
+	defer os.RemoveAll(config.ProjectName)
+
+	if err := config.GenerateProject(); err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	recorded, err := lock.Read(config.ProjectName)
+	if err != nil {
+		t.Fatalf("lock.Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(recorded.Template, config.Source) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", recorded.Template, config.Source)
+	}
+}
+
+func TestGenerateProject_WritesLock(t *testing.T) {
+	workDir := t.TempDir()
+	config := ProjectConfig{
+		ProjectName: filepath.Join(workDir, "lockproject"),
+		ModuleName:  "github.com/test/lockproject",
+		TemplateDir: "templates/local",
+		Templates:   []string{"templates/local/main.go.tmpl", "templates/local/internal/config.go.tmpl"},
+		Variables:   map[string]any{"Port": "9090"},
+		Manifest: &manifest.Manifest{
+			Name:      "local",
+			Version:   "1.4.0",
+			Variables: []manifest.Variable{{Name: "Port", Type: manifest.TypeInt}},
+		},
+		FS: fstest.MapFS{
+			"templates/local/main.go.tmpl":            {Data: []byte("package main\n")},
+			"templates/local/internal/config.go.tmpl": {Data: []byte("package internal // {{.Port}}\n")},
+		},
+		Source: lock.Template{ID: "local", Source: "embedded"},
+	}
+
+	if err := config.GenerateProject(); err != nil {
+		t.Fatalf("GenerateProject() error = %v", err)
+	}
+
+	recorded, err := lock.Read(config.ProjectName)
+	if err != nil {
+		t.Fatalf("lock.Read() error = %v", err)
+	}
+
+	expected := lock.Lock{
+		GoatVersion: version.Version,
+		Template:    lock.Template{ID: "local", Source: "embedded", Version: "1.4.0"},
+		Answers: lock.Answers{
+			ProjectName: config.ProjectName,
+			ModuleName:  "github.com/test/lockproject",
+			Variables:   map[string]any{"Port": 9090},
+		},
+		Files: map[string]string{
+			"main.go":            lock.Hash([]byte("package main\n")),
+			"internal/config.go": lock.Hash([]byte("package internal // 9090\n")),
+		},
+	}
+	if !reflect.DeepEqual(recorded, expected) {
+		t.Errorf("Value not match\nactual = %+v\nexpected = %+v", recorded, expected)
+	}
+
+	for name, hash := range recorded.Files {
+		content, err := os.ReadFile(filepath.Join(config.ProjectName, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if lock.Hash(content) != hash {
+			t.Errorf("Hash of %s does not match the generated file", name)
+		}
+	}
+}
+
+func TestGenerateProject_RollbackOnFailure(t *testing.T) {
+	brokenFS := fstest.MapFS{
+		"templates/local/a.go.tmpl": {Data: []byte("package main")},
+		"templates/local/b.go.tmpl": {Data: []byte("package {{.Missing}}")},
+	}
+
+	tests := []struct {
+		name          string
+		keepOnFailure bool
+	}{
+		{name: "failure removes everything", keepOnFailure: false},
+		{name: "failure keeps the partial project", keepOnFailure: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workDir := t.TempDir()
+			config := ProjectConfig{
+				ProjectName:   filepath.Join(workDir, "rollbackproject"),
+				ModuleName:    "github.com/test/rollbackproject",
+				Templates:     []string{"templates/local/a.go.tmpl", "templates/local/b.go.tmpl"},
+				FS:            brokenFS,
+				KeepOnFailure: tt.keepOnFailure,
+			}
+
+			if err := config.GenerateProject(); err == nil {
+				t.Fatal("GenerateProject() should fail")
+			}
+
+			_, err := os.Stat(config.ProjectName)
+			if tt.keepOnFailure && err != nil {
+				t.Errorf("Expected partial project to be kept: %v", err)
+			}
+			if !tt.keepOnFailure && !os.IsNotExist(err) {
+				t.Errorf("Expected project directory to be removed, got %v", err)
+			}
+
+			entries, err := os.ReadDir(workDir)
+			if err != nil {
+				t.Fatalf("Failed to read work directory: %v", err)
+			}
+			for _, entry := range entries {
+				if strings.HasPrefix(entry.Name(), ".rollbackproject.goat-") {
+					t.Errorf("Staging directory %s was left behind", entry.Name())
+				}
+			}
+
+			// A retry after fixing the templates must not trip over leftovers.
+			if !tt.keepOnFailure {
+				config.Templates = config.Templates[:1]
+				if err := config.GenerateProject(); err != nil {
+					t.Errorf("Retry GenerateProject() error = %v", err)
+				}
+			}
+		})
+	}
+}
+
 func TestBuildDestinationFile(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -361,15 +707,30 @@ func TestBuildDestinationFile(t *testing.T) {
 		expectValue string
 	}{
 		{
-			name:        "is in invisible file list",
-			input:       "gitignore",
+			name:        "dot prefixed file",
+			input:       "dot_gitignore",
 			expectValue: ".gitignore",
 		},
 		{
-			name:        "is not in invisible file list",
+			name:        "regular file",
 			input:       "go.mod",
 			expectValue: "go.mod",
 		},
+		{
+			name:        "nested dot prefixed file",
+			input:       "config/dot_env.example",
+			expectValue: "config/.env.example",
+		},
+		{
+			name:        "dot prefixed directory",
+			input:       "dot_github/workflows/ci.yml",
+			expectValue: ".github/workflows/ci.yml",
+		},
+		{
+			name:        "dot prefix in the middle of a name",
+			input:       "internal/my_dot_file.go",
+			expectValue: "internal/my_dot_file.go",
+		},
 	}
 
 	for _, tt := range tests {
@@ -389,15 +750,20 @@ func TestIsInvisibleFile(t *testing.T) {
 		expectValue bool
 	}{
 		{
-			name:        "is in invisible file list",
-			input:       "gitignore",
+			name:        "dot prefixed name",
+			input:       "dot_golangci.yml",
 			expectValue: true,
 		},
 		{
-			name:        "is not in invisible file list",
+			name:        "regular name",
 			input:       "go.mod",
 			expectValue: false,
 		},
+		{
+			name:        "bare prefix",
+			input:       "dot_",
+			expectValue: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -447,7 +813,7 @@ func TestLoadAndParseTemplate(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			templatePath := tt.templatePath
 
-			tmpl, err := loadAndParseTemplate(templatePath)
+			tmpl, err := loadAndParseTemplate(pkg.Templates, templatePath)
 
 			// Check error expectation
 			if (err != nil) != tt.wantErr {
@@ -466,172 +832,220 @@ func TestLoadAndParseTemplate(t *testing.T) {
 	}
 }
 
-func TestExecuteTemplateToFile(t *testing.T) {
-	tempDir := t.TempDir()
-
-	// Create a simple test template
-	testTemplate := `package main
+func TestExecuteTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		template      string
+		expectedValue string
+		wantErr       string
+	}{
+		{
+			name: "successful template execution",
+			template: `package main
 
 // Module: {{.ModuleName}}
 func main() {
 	println("Hello, {{.ProjectName}}!")
-}`
+}`,
+			expectedValue: `package main
 
-	tmpl, err := template.New("test.tmpl").Parse(testTemplate)
-	if err != nil {
-		t.Fatalf("Failed to create test template: %v", err)
+// Module: github.com/test/testproject
+func main() {
+	println("Hello, testproject!")
+}`,
+		},
+		{
+			name:     "missing field",
+			template: `package {{.InvalidField}}`,
+			wantErr:  "failed to execute template",
+		},
 	}
 
 	config := ProjectConfig{
 		ProjectName: "testproject",
 		ModuleName:  "github.com/test/testproject",
 	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := template.New("test.tmpl").Parse(tt.template)
+			if err != nil {
+				t.Fatalf("Failed to create test template: %v", err)
+			}
 
+			var buf bytes.Buffer
+			err = executeTemplate(tmpl, &buf, config)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("executeTemplate() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("executeTemplate() error = %v", err)
+			}
+			if buf.String() != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %s\nexpected = %s", buf.String(), tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
 	tests := []struct {
-		name         string
-		template     *template.Template
-		outputPath   string
-		config       ProjectConfig
-		wantErr      bool
-		setupFunc    func(t *testing.T) string
-		validateFunc func(t *testing.T, outputPath string, config ProjectConfig)
+		name       string
+		config     ProjectConfig
+		wantErrors []string
 	}{
 		{
-			name:       "successful template execution",
-			template:   tmpl,
-			outputPath: filepath.Join(tempDir, "success_test.go"),
-			config:     config,
-			wantErr:    false,
-			validateFunc: func(t *testing.T, outputPath string, config ProjectConfig) {
-				// Verify file was created
-				if _, err := os.Stat(outputPath); os.IsNotExist(err) {
-					t.Errorf("Output file %s was not created", outputPath)
-					return
-				}
+			name: "valid config",
+			config: ProjectConfig{
+				ProjectName: "testproject",
+				ModuleName:  "github.com/test/testproject",
+			},
+		},
+		{
+			name: "missing project name",
+			config: ProjectConfig{
+				ModuleName: "github.com/test/testproject",
+			},
+			wantErrors: []string{"project name is required"},
+		},
+		{
+			name:       "missing every value",
+			config:     ProjectConfig{},
+			wantErrors: []string{"project name is required", "module name is required"},
+		},
+	}
 
-				// Verify file content
-				content, err := os.ReadFile(outputPath)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.config.Validate()
+			if len(tt.wantErrors) == 0 {
 				if err != nil {
-					t.Errorf("Failed to read output file: %v", err)
-					return
+					t.Errorf("Validate() error = %v, want nil", err)
 				}
-
-				contentStr := string(content)
-				if !strings.Contains(contentStr, config.ProjectName) {
-					t.Errorf("Output file does not contain project name %s", config.ProjectName)
-				}
-				if !strings.Contains(contentStr, config.ModuleName) {
-					t.Errorf("Output file does not contain module name %s", config.ModuleName)
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() error = nil, want %v", tt.wantErrors)
+			}
+			for _, want := range tt.wantErrors {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, should contain %q", err, want)
 				}
-			},
-		},
+			}
+		})
+	}
+}
+
+func TestApplyManifest(t *testing.T) {
+	tests := []struct {
+		name                 string
+		files                []manifest.File
+		expectedTemplates    []string
+		expectedDestinations map[string]string
+		wantErr              bool
+	}{
 		{
-			name:       "invalid output directory",
-			template:   tmpl,
-			outputPath: "/root/nonexistent/directory/test.go",
-			config:     config,
-			wantErr:    true,
+			name:                 "files inside the template",
+			files:                []manifest.File{{Src: "main.go.tmpl"}, {Src: "server.go.tmpl", Dest: "cmd/server/main.go"}},
+			expectedTemplates:    []string{"templates/api/main.go.tmpl", "templates/api/server.go.tmpl"},
+			expectedDestinations: map[string]string{"templates/api/server.go.tmpl": "cmd/server/main.go"},
 		},
 		{
-			name:       "empty output path",
-			template:   tmpl,
-			outputPath: "",
-			config:     config,
-			wantErr:    true,
+			name:                 "file from a sibling template",
+			files:                []manifest.File{{Src: "../base/dot_gitignore.tmpl"}},
+			expectedTemplates:    []string{"templates/base/dot_gitignore.tmpl"},
+			expectedDestinations: map[string]string{},
 		},
 		{
-			name:       "output path with null bytes",
-			template:   tmpl,
-			outputPath: filepath.Join(tempDir, "test\x00.go"),
-			config:     config,
-			wantErr:    true,
+			name:    "file outside of the templates root",
+			files:   []manifest.File{{Src: "../../go.mod"}},
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			outputPath := tt.outputPath
-
-			// Setup test if needed
-			if tt.setupFunc != nil {
-				outputPath = tt.setupFunc(t)
-			}
-
-			err := executeTemplateToFile(tt.template, outputPath, tt.config)
-
-			// Check error expectation
+			config := ProjectConfig{}
+			err := config.ApplyManifest("templates/api", manifest.Manifest{Name: "API", Files: tt.files})
 			if (err != nil) != tt.wantErr {
-				t.Errorf("executeTemplateToFile() error = %v, wantErr %v", err, tt.wantErr)
+				t.Fatalf("ApplyManifest() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
 				return
 			}
-
-			// Run validation if test should succeed
-			if !tt.wantErr && tt.validateFunc != nil {
-				tt.validateFunc(t, outputPath, tt.config)
+			if !reflect.DeepEqual(config.Templates, tt.expectedTemplates) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", config.Templates, tt.expectedTemplates)
+			}
+			if !reflect.DeepEqual(config.Destinations, tt.expectedDestinations) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", config.Destinations, tt.expectedDestinations)
+			}
+			if config.Manifest == nil {
+				t.Error("Expected manifest to be set")
 			}
 		})
 	}
 }
 
-func TestExecuteTemplateToFile_TemplateExecutionError(t *testing.T) {
-	tempDir := t.TempDir()
-
-	// Create a template with invalid syntax that will cause execution error
-	invalidTemplate := `package {{.InvalidField}}`
-
-	tmpl, err := template.New("invalid.tmpl").Parse(invalidTemplate)
+func TestExecuteTemplate_ManifestVariables(t *testing.T) {
+	tmpl, err := template.New("vars.tmpl").Parse(`{{.ProjectName}} listens on :{{.Port}} docker={{.Docker}}`)
 	if err != nil {
-		t.Fatalf("Failed to create invalid test template: %v", err)
+		t.Fatalf("Failed to create test template: %v", err)
 	}
 
 	config := ProjectConfig{
 		ProjectName: "testproject",
 		ModuleName:  "github.com/test/testproject",
+		Variables:   map[string]any{"Port": "9000"},
+		Manifest: &manifest.Manifest{
+			Name: "API",
+			Variables: []manifest.Variable{
+				{Name: "Port", Type: manifest.TypeInt, Default: 3000},
+				{Name: "Docker", Type: manifest.TypeBool},
+			},
+		},
 	}
-
-	outputPath := filepath.Join(tempDir, "invalid_execution.go")
-
-	err = executeTemplateToFile(tmpl, outputPath, config)
-	if err == nil {
-		t.Error("executeTemplateToFile() should have failed with template execution error")
+	resolved, err := config.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
 	}
-
-	// Verify that error message contains template execution information
-	if !strings.Contains(err.Error(), "failed to execute template") {
-		t.Errorf("Error message should contain 'failed to execute template', got: %v", err)
+	if _, ok := config.Variables["Docker"]; ok {
+		t.Error("Resolve() must not change the config it is called on")
 	}
-}
-
-func TestExecuteTemplateToFile_FilePermissions(t *testing.T) {
-	tempDir := t.TempDir()
 
-	testTemplate := `package {{.ProjectName}}`
-	tmpl, err := template.New("perm.tmpl").Parse(testTemplate)
-	if err != nil {
-		t.Fatalf("Failed to create test template: %v", err)
+	var buf bytes.Buffer
+	if err := executeTemplate(tmpl, &buf, resolved); err != nil {
+		t.Fatalf("executeTemplate() error = %v", err)
 	}
 
-	config := ProjectConfig{
-		ProjectName: "testproject",
-		ModuleName:  "github.com/test/testproject",
+	expected := "testproject listens on :9000 docker=false"
+	if buf.String() != expected {
+		t.Errorf("Value not match\nactual = %s\nexpected = %s", buf.String(), expected)
 	}
+}
 
-	outputPath := filepath.Join(tempDir, "perm_test.go")
-
-	err = executeTemplateToFile(tmpl, outputPath, config)
-	if err != nil {
-		t.Fatalf("executeTemplateToFile() failed: %v", err)
+func TestValidate_ManifestVariables(t *testing.T) {
+	config := ProjectConfig{
+		Variables: map[string]any{"Color": "red"},
+		Manifest: &manifest.Manifest{
+			Name:      "API",
+			Variables: []manifest.Variable{{Name: "Resource", Required: true}},
+		},
 	}
 
-	// Check file permissions
-	info, err := os.Stat(outputPath)
-	if err != nil {
-		t.Fatalf("Failed to stat output file: %v", err)
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate() should fail")
 	}
-
-	// Files created with os.Create should have default permissions (usually 0644)
-	expectedMode := os.FileMode(0644)
-	if info.Mode().Perm() != expectedMode {
-		t.Logf("File permissions = %v, expected around %v (actual permissions may vary by system)", info.Mode().Perm(), expectedMode)
+	for _, want := range []string{
+		"project name is required",
+		"module name is required",
+		"unknown variable Color",
+		"variable Resource is required",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() error = %v, should contain %q", err, want)
+		}
 	}
 }
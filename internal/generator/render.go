@@ -0,0 +1,114 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/version"
+)
+
+// RenderedFile is a generated file held in memory. Path is relative to the
+// project directory and uses forward slashes.
+type RenderedFile struct {
+	Path     string
+	Template string
+	Content  []byte
+}
+
+// Render runs the whole generation pipeline in memory, without touching the
+// disk, and returns the files sorted by path.
+func (config ProjectConfig) Render() ([]RenderedFile, error) {
+	config, err := config.Resolve()
+	if err != nil {
+		return nil, err
+	}
+
+	templateFiles, err := mapTemplates(config, "")
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]RenderedFile, 0, len(templateFiles)+1)
+	for tmplPath, outputPath := range templateFiles {
+		content, err := renderFile(tmplPath, outputPath, config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to process template %s: %w", tmplPath, err)
+		}
+		if config.skipEmpty(tmplPath, content) {
+			continue
+		}
+		files = append(files, RenderedFile{Path: filepath.ToSlash(outputPath), Template: tmplPath, Content: content})
+	}
+
+	content, err := lock.Encode(config.lockFile(files))
+	if err != nil {
+		return nil, err
+	}
+	files = append(files, RenderedFile{Path: lock.FileName, Content: content})
+
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// lockFile records the template, the answers and the hash of every rendered file.
+func (config ProjectConfig) lockFile(files []RenderedFile) lock.Lock {
+	source := config.Source
+	if source.Version == "" && config.Manifest != nil {
+		source.Version = config.Manifest.Version
+	}
+
+	hashes := make(map[string]string, len(files))
+	for _, f := range files {
+		hashes[f.Path] = lock.Hash(f.Content)
+	}
+
+	var variables map[string]any
+	if len(config.Variables) != 0 {
+		variables = config.Variables
+	}
+	return lock.Lock{
+		GoatVersion: version.Version,
+		Template:    source,
+		Answers: lock.Answers{
+			ProjectName: config.ProjectName,
+			ModuleName:  config.ModuleName,
+			Variables:   variables,
+		},
+		Files: hashes,
+	}
+}
+
+// renderFile renders the template for the project file rel, Go files are
+// formatted.
+func renderFile(templatePath, rel string, config ProjectConfig) ([]byte, error) {
+	if strings.HasSuffix(rel, ".go") {
+		return renderGoTemplate(templatePath, config)
+	}
+	return renderTemplate(templatePath, config)
+}
+
+func renderTemplate(templatePath string, config ProjectConfig) ([]byte, error) {
+	tmpl, err := loadAndParseTemplate(config.templatesFS(), templatePath, config.partialDirs(templatePath)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load template %s: %w", templatePath, err)
+	}
+
+	var buf bytes.Buffer
+	if err := executeTemplate(tmpl, &buf, config); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", templatePath, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func executeTemplate(tmpl *template.Template, w io.Writer, config ProjectConfig) error {
+	if err := tmpl.Option("missingkey=error").Execute(w, config.templateData()); err != nil {
+		return fmt.Errorf("failed to execute template: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,377 @@
+package generator
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/manifest"
+)
+
+func TestRender(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "renderproject",
+		ModuleName:  "github.com/test/renderproject",
+		TemplateDir: "templates/local",
+		Templates: []string{
+			"templates/local/main.go.tmpl",
+			"templates/local/internal/handler/health.go.tmpl",
+		},
+		FS: fstest.MapFS{
+			"templates/local/main.go.tmpl":                    {Data: []byte("package main // {{.ModuleName}}")},
+			"templates/local/internal/handler/health.go.tmpl": {Data: []byte("package handler")},
+		},
+		Source: lock.Template{ID: "local", Source: "git+file:///repo", Commit: "abc123"},
+	}
+
+	files, err := config.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	expected := []RenderedFile{
+		{Path: lock.FileName},
+		{Path: "internal/handler/health.go", Template: "templates/local/internal/handler/health.go.tmpl", Content: []byte("package handler\n")},
+		{Path: "main.go", Template: "templates/local/main.go.tmpl", Content: []byte("package main // github.com/test/renderproject\n")},
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Length not match\nactual = %v\nexpected = %v", len(files), len(expected))
+	}
+	for i, f := range files {
+		if f.Path != expected[i].Path || f.Template != expected[i].Template {
+			t.Errorf("File not match\nactual = %v (%v)\nexpected = %v (%v)", f.Path, f.Template, expected[i].Path, expected[i].Template)
+		}
+		if expected[i].Content != nil && !bytes.Equal(f.Content, expected[i].Content) {
+			t.Errorf("Content not match\nactual = %s\nexpected = %s", f.Content, expected[i].Content)
+		}
+	}
+	if !strings.Contains(string(files[0].Content), "abc123") {
+		t.Errorf("Lockfile does not record the commit: %s", files[0].Content)
+	}
+
+	if _, err := os.Stat(config.ProjectName); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Render() must not create %s, stat error = %v", config.ProjectName, err)
+	}
+}
+
+func TestRender_Layers(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "layered",
+		ModuleName:  "github.com/test/layered",
+		FS: fstest.MapFS{
+			"templates/base/dot_gitignore.tmpl": {Data: []byte("bin/")},
+			"templates/base/README.md.tmpl":     {Data: []byte("# {{.ProjectName}}")},
+			"templates/api/README.md.tmpl":      {Data: []byte("# {{.ProjectName}} listens on {{.Port}}")},
+			"templates/api/main.go.tmpl":        {Data: []byte("package main")},
+		},
+	}
+	err := config.ApplyLayers(
+		Layer{Dir: "templates/base", Manifest: manifest.Manifest{
+			Name:      "Base",
+			Variables: []manifest.Variable{{Name: "Port", Type: "int", Default: 3000}},
+			Files:     []manifest.File{{Src: "dot_gitignore.tmpl"}, {Src: "README.md.tmpl"}},
+		}},
+		Layer{Dir: "templates/api", Manifest: manifest.Manifest{
+			Name:      "API",
+			Variables: []manifest.Variable{{Name: "Port", Type: "int", Default: 8080}},
+			Files:     []manifest.File{{Src: "main.go.tmpl"}, {Src: "README.md.tmpl"}},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("ApplyLayers() error = %v", err)
+	}
+	config.Variables = map[string]any{"Port": 8080}
+
+	files, err := config.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	expected := []RenderedFile{
+		{Path: ".gitignore", Template: "templates/base/dot_gitignore.tmpl", Content: []byte("bin/")},
+		{Path: lock.FileName},
+		{Path: "README.md", Template: "templates/api/README.md.tmpl", Content: []byte("# layered listens on 8080")},
+		{Path: "main.go", Template: "templates/api/main.go.tmpl", Content: []byte("package main\n")},
+	}
+	if len(files) != len(expected) {
+		t.Fatalf("Length not match\nactual = %v\nexpected = %v", len(files), len(expected))
+	}
+	for i, f := range files {
+		if f.Path != expected[i].Path || f.Template != expected[i].Template {
+			t.Errorf("File not match\nactual = %v (%v)\nexpected = %v (%v)", f.Path, f.Template, expected[i].Path, expected[i].Template)
+		}
+		if expected[i].Content != nil && !bytes.Equal(f.Content, expected[i].Content) {
+			t.Errorf("Content not match\nactual = %s\nexpected = %s", f.Content, expected[i].Content)
+		}
+	}
+	if config.Manifest.Name != "API" || len(config.Manifest.Variables) != 1 || config.Manifest.Variables[0].Default != 8080 {
+		t.Errorf("Unexpected merged manifest: %+v", config.Manifest)
+	}
+}
+
+func TestApplyLayers_Overlay(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "overlaid",
+		ModuleName:  "github.com/test/overlaid",
+		Source:      lock.Template{ID: "api"},
+		FS: fstest.MapFS{
+			"templates/api/main.go.tmpl":        {Data: []byte("package main")},
+			"templates/company/CODEOWNERS.tmpl": {Data: []byte("* @{{.Team}}")},
+		},
+	}
+	err := config.ApplyLayers(
+		Layer{Dir: "templates/api", Manifest: manifest.Manifest{
+			Name:         "API",
+			Version:      "1.2.0",
+			Files:        []manifest.File{{Src: "main.go.tmpl"}},
+			Dependencies: []manifest.Dependency{{Module: "github.com/gin-gonic/gin"}},
+		}},
+		Layer{Dir: "templates/company", Overlay: true, Manifest: manifest.Manifest{
+			Name:         "Company",
+			Version:      "0.1.0",
+			Variables:    []manifest.Variable{{Name: "Team", Type: "string", Default: "platform"}},
+			Files:        []manifest.File{{Src: "CODEOWNERS.tmpl"}},
+			Dependencies: []manifest.Dependency{{Module: "github.com/google/uuid"}},
+		}},
+	)
+	if err != nil {
+		t.Fatalf("ApplyLayers() error = %v", err)
+	}
+	if config.TemplateDir != "templates/api" {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", config.TemplateDir, "templates/api")
+	}
+	if config.Manifest.Name != "API" || config.Manifest.Version != "1.2.0" || len(config.Manifest.Dependencies) != 1 {
+		t.Errorf("Unexpected merged manifest: %+v", config.Manifest)
+	}
+
+	files, err := config.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	recorded := config.lockFile(files)
+	if recorded.Template.Version != "1.2.0" {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", recorded.Template.Version, "1.2.0")
+	}
+	if _, ok := recorded.Files["CODEOWNERS"]; !ok {
+		t.Errorf("Expected CODEOWNERS in the lock, got %v", recorded.Files)
+	}
+}
+
+func TestRender_Error(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "renderproject",
+		ModuleName:  "github.com/test/renderproject",
+		Templates:   []string{"templates/local/main.go.tmpl"},
+		FS: fstest.MapFS{
+			"templates/local/main.go.tmpl": {Data: []byte("{{.Missing}}")},
+		},
+	}
+
+	if _, err := config.Render(); err == nil {
+		t.Error("Render() expected an error for a missing key")
+	}
+}
+
+func TestRender_SkipsEmptyFiles(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "emptyproject",
+		ModuleName:  "github.com/test/emptyproject",
+		Templates: []string{
+			"templates/local/main.go.tmpl",
+			"templates/local/docker.go.tmpl",
+			"templates/local/dot_keep.tmpl",
+		},
+		KeepEmpty: map[string]bool{"templates/local/dot_keep.tmpl": true},
+		Variables: map[string]any{"Docker": false},
+		FS: fstest.MapFS{
+			"templates/local/main.go.tmpl":   {Data: []byte("package main")},
+			"templates/local/docker.go.tmpl": {Data: []byte("{{if .Docker}}package docker{{end}}\n\n")},
+			"templates/local/dot_keep.tmpl":  {Data: []byte("")},
+		},
+	}
+
+	files, err := config.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	expected := []string{".goat.lock", ".keep", "main.go"}
+	if strings.Join(paths, ",") != strings.Join(expected, ",") {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", paths, expected)
+	}
+}
+
+func TestRender_Partials(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "partialproject",
+		ModuleName:  "github.com/test/partialproject",
+		TemplateDir: "templates/api",
+		Templates:   []string{"templates/api/main.go.tmpl", "templates/api/server.go.tmpl"},
+		FS: fstest.MapFS{
+			"templates/_partials/logging.tmpl":      {Data: []byte(`{{define "logging"}}log.Println("{{.ProjectName}}"){{end}}`)},
+			"templates/_partials/shutdown.tmpl":     {Data: []byte(`{{define "graceful_shutdown"}}// shared shutdown{{end}}`)},
+			"templates/api/_partials/shutdown.tmpl": {Data: []byte(`{{define "graceful_shutdown"}}// api shutdown{{end}}`)},
+			"templates/api/main.go.tmpl":            {Data: []byte("package main\n\nfunc main() {\n{{template \"logging\" .}} {{template \"graceful_shutdown\" .}}\n}\n")},
+			"templates/api/server.go.tmpl":          {Data: []byte("package main\n\nfunc serve() {\n{{template \"logging\" .}}\n}\n")},
+		},
+	}
+
+	files, err := config.Render()
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+
+	expected := map[string]string{
+		"main.go":   "package main\n\nfunc main() {\n\tlog.Println(\"partialproject\") // api shutdown\n}\n",
+		"server.go": "package main\n\nfunc serve() {\n\tlog.Println(\"partialproject\")\n}\n",
+	}
+	for _, f := range files {
+		if want, ok := expected[f.Path]; ok && string(f.Content) != want {
+			t.Errorf("Value not match\nactual = %s\nexpected = %s", f.Content, want)
+		}
+	}
+}
+
+func TestRender_EmbeddedSharedPartials(t *testing.T) {
+	for _, id := range []string{"fiber", "gin"} {
+		t.Run(id, func(t *testing.T) {
+			config := ProjectConfig{
+				ProjectName: "shared",
+				ModuleName:  "github.com/test/shared",
+				TemplateDir: "templates/" + id,
+				Templates:   []string{"templates/" + id + "/main.go.tmpl"},
+			}
+
+			files, err := config.Render()
+			if err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+			for _, f := range files {
+				if f.Path != "main.go" {
+					continue
+				}
+				for _, want := range []string{"func newLogger() *slog.Logger", `With("service", "shared")`, "func run(logger *slog.Logger"} {
+					if !strings.Contains(string(f.Content), want) {
+						t.Errorf("main.go does not contain %q:\n%s", want, f.Content)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestRender_UnknownPartial(t *testing.T) {
+	config := ProjectConfig{
+		ProjectName: "partialproject",
+		ModuleName:  "github.com/test/partialproject",
+		Templates:   []string{"templates/api/main.go.tmpl"},
+		FS: fstest.MapFS{
+			"templates/api/main.go.tmpl": {Data: []byte(`{{template "missing" .}}`)},
+		},
+	}
+
+	if _, err := config.Render(); err == nil {
+		t.Error("Render() expected an error for an undefined partial")
+	}
+}
+
+var dryRunFiles = []RenderedFile{
+	{Path: ".gitignore", Content: []byte("bin/\n")},
+	{Path: "internal/handler/health.go", Content: []byte("package handler")},
+	{Path: "main.go", Content: bytes.Repeat([]byte("a"), 2048)},
+}
+
+func TestWriteTree(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteTree(&buf, "demo", dryRunFiles); err != nil {
+		t.Fatalf("WriteTree() error = %v", err)
+	}
+
+	expected := `demo/
+├── .gitignore (5 B)
+├── internal/
+│   └── handler/
+│       └── health.go (15 B)
+└── main.go (2.0 KB)
+
+3 files, 2.0 KB
+`
+	if buf.String() != expected {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", buf.String(), expected)
+	}
+}
+
+func TestWriteContents(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteContents(&buf, "demo", dryRunFiles[:2]); err != nil {
+		t.Fatalf("WriteContents() error = %v", err)
+	}
+
+	expected := "==> demo/.gitignore <==\nbin/\n==> demo/internal/handler/health.go <==\npackage handler\n"
+	if buf.String() != expected {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", buf.String(), expected)
+	}
+}
+
+func TestWriteTar(t *testing.T) {
+	tests := []struct {
+		name          string
+		root          string
+		expectedValue []string
+	}{
+		{
+			name:          "project directory",
+			root:          "demo",
+			expectedValue: []string{"demo/", "demo/internal/", "demo/internal/handler/", "demo/.gitignore", "demo/internal/handler/health.go", "demo/main.go"},
+		},
+		{
+			name:          "current directory",
+			root:          ".",
+			expectedValue: []string{"internal/", "internal/handler/", ".gitignore", "internal/handler/health.go", "main.go"},
+		},
+		{
+			name:          "absolute directory",
+			root:          "/srv/demo/",
+			expectedValue: []string{"srv/", "srv/demo/", "srv/demo/internal/", "srv/demo/internal/handler/", "srv/demo/.gitignore", "srv/demo/internal/handler/health.go", "srv/demo/main.go"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteTar(&buf, tt.root, dryRunFiles); err != nil {
+				t.Fatalf("WriteTar() error = %v", err)
+			}
+
+			var names []string
+			tr := tar.NewReader(&buf)
+			for {
+				header, err := tr.Next()
+				if errors.Is(err, io.EOF) {
+					break
+				}
+				if err != nil {
+					t.Fatalf("Failed to read tar: %v", err)
+				}
+				names = append(names, header.Name)
+				if strings.HasSuffix(header.Name, "main.go") && header.Size != 2048 {
+					t.Errorf("Size not match\nactual = %v\nexpected = %v", header.Size, 2048)
+				}
+			}
+
+			if strings.Join(names, ",") != strings.Join(tt.expectedValue, ",") {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", names, tt.expectedValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/smilepakawat/goat/internal/runner"
+)
+
+const (
+	DefaultBranch  = "main"
+	DefaultMessage = "Initial commit"
+)
+
+var ErrNotFound = errors.New("git is not installed")
+
+type Options struct {
+	// Branch is the initial branch. When empty, init.defaultBranch of the
+	// user is used, or DefaultBranch if that is not set either.
+	Branch string
+	// Message of the initial commit, DefaultMessage when empty.
+	Message string
+}
+
+type Result struct {
+	// InsideRepo is set when the directory already belongs to a work tree,
+	// nothing is done then.
+	InsideRepo bool
+	Branch     string
+	// Commit is the hash of the initial commit. It is empty when git has no
+	// author configured, the files are only staged then.
+	Commit string
+}
+
+// Init turns dir into a git repository on the chosen branch, stages every
+// file and commits them as the author from the git config of the user.
+func Init(ctx context.Context, dir string, opts Options) (Result, error) {
+	if _, err := exec.LookPath("git"); err != nil {
+		return Result{}, ErrNotFound
+	}
+	if out, err := git(ctx, dir, "rev-parse", "--is-inside-work-tree"); err == nil && out == "true" {
+		return Result{InsideRepo: true}, nil
+	}
+
+	branch := opts.Branch
+	if branch == "" {
+		branch, _ = git(ctx, dir, "config", "--get", "init.defaultBranch")
+	}
+	if branch == "" {
+		branch = DefaultBranch
+	}
+	if _, err := git(ctx, dir, "check-ref-format", "--branch", branch); err != nil {
+		return Result{}, fmt.Errorf("invalid branch name %q", branch)
+	}
+
+	if _, err := git(ctx, dir, "init", "--quiet"); err != nil {
+		return Result{}, err
+	}
+	// Set HEAD instead of using init -b, which needs git 2.28.
+	if _, err := git(ctx, dir, "symbolic-ref", "HEAD", "refs/heads/"+branch); err != nil {
+		return Result{}, err
+	}
+	if _, err := git(ctx, dir, "add", "--all"); err != nil {
+		return Result{}, err
+	}
+	result := Result{Branch: branch}
+	if !hasAuthor(ctx, dir) {
+		return result, nil
+	}
+
+	message := opts.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+	if _, err := git(ctx, dir, "commit", "--quiet", "--no-verify", "-m", message); err != nil {
+		return result, err
+	}
+	commit, err := git(ctx, dir, "rev-parse", "HEAD")
+	if err != nil {
+		return result, err
+	}
+	result.Commit = commit
+	return result, nil
+}
+
+// hasAuthor reports whether git knows who to commit as, without falling back
+// to a name made up from the user and host name.
+func hasAuthor(ctx context.Context, dir string) bool {
+	name := os.Getenv("GIT_AUTHOR_NAME")
+	if name == "" {
+		name, _ = git(ctx, dir, "config", "--get", "user.name")
+	}
+	email := os.Getenv("GIT_AUTHOR_EMAIL")
+	if email == "" {
+		email, _ = git(ctx, dir, "config", "--get", "user.email")
+	}
+	return name != "" && email != ""
+}
+
+// git runs a git command and returns its trimmed output. Errors carry what
+// git printed to stderr.
+func git(ctx context.Context, dir string, args ...string) (string, error) {
+	out, err := runner.Output(ctx, runner.Command{Name: "git", Args: args, Dir: dir})
+	if err != nil {
+		var runErr *runner.Error
+		if errors.As(err, &runErr) && strings.TrimSpace(runErr.Output) != "" {
+			return "", fmt.Errorf("%w: %s", err, strings.TrimSpace(runErr.Output))
+		}
+		return "", err
+	}
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,180 @@
+package gitrepo
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// isolate points git at a global config with the given content, so the
+// tests do not depend on the git config of the machine.
+func isolate(t *testing.T, config string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	home := t.TempDir()
+	global := filepath.Join(home, ".gitconfig")
+	if err := os.WriteFile(global, []byte(config), 0644); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", home)
+	t.Setenv("GIT_CONFIG_GLOBAL", global)
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	for _, name := range []string{"GIT_AUTHOR_NAME", "GIT_AUTHOR_EMAIL", "GIT_COMMITTER_NAME", "GIT_COMMITTER_EMAIL"} {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+	t.Setenv("GIT_CEILING_DIRECTORIES", filepath.Dir(home))
+}
+
+func project(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range map[string]string{"main.go": "package main\n", "internal/app/app.go": "package app\n"} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+	}
+	return dir
+}
+
+func gitOutput(t *testing.T, dir string, args ...string) string {
+	t.Helper()
+	out, err := git(context.Background(), dir, args...)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	return out
+}
+
+func TestInit(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         string
+		opts           Options
+		expectedBranch string
+		expectedAuthor string
+	}{
+		{
+			name:           "default branch",
+			config:         "[user]\n\tname = Goat\n\temail = goat@example.com\n",
+			expectedBranch: DefaultBranch,
+			expectedAuthor: "Goat <goat@example.com>",
+		},
+		{
+			name:           "default branch of the user",
+			config:         "[user]\n\tname = Goat\n\temail = goat@example.com\n[init]\n\tdefaultBranch = trunk\n",
+			expectedBranch: "trunk",
+			expectedAuthor: "Goat <goat@example.com>",
+		},
+		{
+			name:           "explicit branch and message",
+			config:         "[user]\n\tname = Goat\n\temail = goat@example.com\n[init]\n\tdefaultBranch = trunk\n",
+			opts:           Options{Branch: "develop", Message: "Scaffold project"},
+			expectedBranch: "develop",
+			expectedAuthor: "Goat <goat@example.com>",
+		},
+		{
+			name:           "no author",
+			expectedBranch: DefaultBranch,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isolate(t, tt.config)
+			dir := project(t)
+
+			result, err := Init(context.Background(), dir, tt.opts)
+			if err != nil {
+				t.Fatalf("Init() error = %v", err)
+			}
+			if result.Branch != tt.expectedBranch {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", result.Branch, tt.expectedBranch)
+			}
+			if head := gitOutput(t, dir, "symbolic-ref", "--short", "HEAD"); head != tt.expectedBranch {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", head, tt.expectedBranch)
+			}
+
+			staged := gitOutput(t, dir, "ls-files")
+			if staged != "internal/app/app.go\nmain.go" {
+				t.Errorf("Unexpected staged files: %q", staged)
+			}
+
+			if tt.expectedAuthor == "" {
+				if result.Commit != "" {
+					t.Errorf("Expected no commit without an author, got %s", result.Commit)
+				}
+				return
+			}
+			if result.Commit != gitOutput(t, dir, "rev-parse", "HEAD") {
+				t.Errorf("Result commit %s is not HEAD", result.Commit)
+			}
+			author := gitOutput(t, dir, "log", "-1", "--format=%an <%ae>")
+			if author != tt.expectedAuthor {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", author, tt.expectedAuthor)
+			}
+			message := gitOutput(t, dir, "log", "-1", "--format=%s")
+			expectedMessage := tt.opts.Message
+			if expectedMessage == "" {
+				expectedMessage = DefaultMessage
+			}
+			if message != expectedMessage {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", message, expectedMessage)
+			}
+			if status := gitOutput(t, dir, "status", "--porcelain"); status != "" {
+				t.Errorf("Expected a clean work tree, got %q", status)
+			}
+		})
+	}
+}
+
+func TestInit_InsideRepo(t *testing.T) {
+	isolate(t, "[user]\n\tname = Goat\n\temail = goat@example.com\n")
+	parent := project(t)
+	gitOutput(t, parent, "init", "--quiet")
+	dir := filepath.Join(parent, "internal")
+
+	result, err := Init(context.Background(), dir, Options{})
+	if err != nil {
+		t.Fatalf("Init() error = %v", err)
+	}
+	if !result.InsideRepo {
+		t.Error("Expected InsideRepo for a directory inside a work tree")
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Init() must not create a nested repository, stat error = %v", err)
+	}
+}
+
+func TestInit_InvalidBranch(t *testing.T) {
+	isolate(t, "")
+	dir := project(t)
+
+	_, err := Init(context.Background(), dir, Options{Branch: "bad..name"})
+	if err == nil || !strings.Contains(err.Error(), "invalid branch name") {
+		t.Fatalf("Init() error = %v, want an invalid branch name", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, ".git")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Init() must not create a repository, stat error = %v", err)
+	}
+}
+
+func TestInit_GitNotFound(t *testing.T) {
+	t.Setenv("PATH", t.TempDir())
+
+	_, err := Init(context.Background(), t.TempDir(), Options{})
+	if !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Init() error = %v, want ErrNotFound", err)
+	}
+}
@@ -0,0 +1,151 @@
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+const FileName = ".goat.lock"
+
+// Lock records where a generated project came from, the answers it was
+// generated with and a hash of every rendered file, so later runs can tell
+// which files were changed by hand. Rendered keeps the hash a file had when
+// it was rendered for files goat changed afterwards, see Rehash.
+type Lock struct {
+	GoatVersion string            `yaml:"goatVersion"`
+	Template    Template          `yaml:"template"`
+	Answers     Answers           `yaml:"answers"`
+	Files       map[string]string `yaml:"files"`
+	Rendered    map[string]string `yaml:"rendered,omitempty"`
+}
+
+type Template struct {
+	ID       string   `yaml:"id"`
+	Source   string   `yaml:"source"`
+	Version  string   `yaml:"version,omitempty"`
+	Commit   string   `yaml:"commit,omitempty"`
+	Overlays []string `yaml:"overlays,omitempty"`
+}
+
+type Answers struct {
+	ProjectName string         `yaml:"projectName"`
+	ModuleName  string         `yaml:"moduleName"`
+	Variables   map[string]any `yaml:"variables,omitempty"`
+}
+
+// Hash returns the digest recorded for a rendered file.
+func Hash(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func Encode(lock Lock) ([]byte, error) {
+	content, err := yaml.Marshal(lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode lockfile: %w", err)
+	}
+	return content, nil
+}
+
+func Write(projectDir string, lock Lock) error {
+	content, err := Encode(lock)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(projectDir, FileName), content, 0644); err != nil {
+		return fmt.Errorf("failed to write lockfile: %w", err)
+	}
+	return nil
+}
+
+func Read(projectDir string) (Lock, error) {
+	content, err := os.ReadFile(filepath.Join(projectDir, FileName))
+	if err != nil {
+		return Lock{}, fmt.Errorf("failed to read lockfile: %w", err)
+	}
+
+	return Decode(content)
+}
+
+func Decode(content []byte) (Lock, error) {
+	var lock Lock
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return Lock{}, fmt.Errorf("failed to parse lockfile: %w", err)
+	}
+	return lock, nil
+}
+
+// RenderedHash returns the hash p had when it was rendered, which differs
+// from the recorded one when goat changed the file after rendering.
+func (lock Lock) RenderedHash(p string) string {
+	if hash, ok := lock.Rendered[p]; ok {
+		return hash
+	}
+	return lock.Files[p]
+}
+
+// Pristine returns the recorded files of the project in projectDir that are
+// unchanged since they were generated, sorted by path.
+func Pristine(projectDir string) ([]string, error) {
+	lock, err := Read(projectDir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for p, hash := range lock.Files {
+		content, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(p)))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		if Hash(content) == hash {
+			files = append(files, p)
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// Rehash records the current content of files in the lock of the project in
+// projectDir. It is used for files goat changes itself after rendering, such
+// as go.mod after go get and go mod tidy, which are still as generated even
+// though they differ from the template. The hash they were rendered with is
+// kept in Rendered so goat update can still tell template changes apart.
+// Files that no longer exist are dropped from the lock.
+func Rehash(projectDir string, files []string) error {
+	lock, err := Read(projectDir)
+	if err != nil {
+		return err
+	}
+
+	for _, p := range files {
+		content, err := os.ReadFile(filepath.Join(projectDir, filepath.FromSlash(p)))
+		if errors.Is(err, os.ErrNotExist) {
+			delete(lock.Files, p)
+			delete(lock.Rendered, p)
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		hash := Hash(content)
+		if rendered := lock.RenderedHash(p); hash != rendered {
+			if lock.Rendered == nil {
+				lock.Rendered = make(map[string]string)
+			}
+			lock.Rendered[p] = rendered
+		}
+		lock.Files[p] = hash
+	}
+	return Write(projectDir, lock)
+}
@@ -0,0 +1,113 @@
+package lock
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestWriteRead(t *testing.T) {
+	projectDir := t.TempDir()
+	expected := Lock{
+		GoatVersion: "0.2.0",
+		Template: Template{
+			ID:      "api",
+			Source:  "git+file:///srv/templates.git//api@v1.2.0",
+			Version: "1.2.0",
+			Commit:  "0123456789abcdef0123456789abcdef01234567",
+		},
+		Answers: Answers{
+			ProjectName: "svc",
+			ModuleName:  "github.com/test/svc",
+			Variables:   map[string]any{"Port": 8080, "Docker": true, "Database": "postgres"},
+		},
+		Files: map[string]string{
+			"main.go": Hash([]byte("package main")),
+		},
+	}
+
+	if err := Write(projectDir, expected); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	actual, err := Read(projectDir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, expected)
+	}
+}
+
+func TestRead_Missing(t *testing.T) {
+	if _, err := Read(t.TempDir()); err == nil {
+		t.Error("Read() should fail without a lockfile")
+	}
+}
+
+func TestHash(t *testing.T) {
+	expected := "sha256:a665a45920422f9d417e4867efdc4fb8a04a1f3fff1fa07e998e86f7f7a27ae3"
+	if actual := Hash([]byte("123")); actual != expected {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, expected)
+	}
+}
+
+func TestPristineRehash(t *testing.T) {
+	projectDir := t.TempDir()
+	files := map[string]string{"go.mod": "module svc\n", "main.go": "package main\n", "README.md": "# svc\n", "Makefile": "all:\n"}
+	hashes := make(map[string]string, len(files))
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(projectDir, name), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+		hashes[name] = Hash([]byte(content))
+	}
+	if err := Write(projectDir, Lock{Files: hashes}); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+
+	// README.md was skipped when generating into an existing directory.
+	if err := os.WriteFile(filepath.Join(projectDir, "README.md"), []byte("# existing\n"), 0644); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	pristine, err := Pristine(projectDir)
+	if err != nil {
+		t.Fatalf("Pristine() error = %v", err)
+	}
+	expected := []string{"Makefile", "go.mod", "main.go"}
+	if !reflect.DeepEqual(pristine, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", pristine, expected)
+	}
+
+	// go get rewrote go.mod and a hook removed the Makefile.
+	tidy := "module svc\n\nrequire example.com/dep v1.0.0\n"
+	if err := os.WriteFile(filepath.Join(projectDir, "go.mod"), []byte(tidy), 0644); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	if err := os.Remove(filepath.Join(projectDir, "Makefile")); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	if err := Rehash(projectDir, pristine); err != nil {
+		t.Fatalf("Rehash() error = %v", err)
+	}
+
+	actual, err := Read(projectDir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	expectedFiles := map[string]string{
+		"go.mod":    Hash([]byte(tidy)),
+		"main.go":   hashes["main.go"],
+		"README.md": hashes["README.md"],
+	}
+	if !reflect.DeepEqual(actual.Files, expectedFiles) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual.Files, expectedFiles)
+	}
+	expectedRendered := map[string]string{"go.mod": hashes["go.mod"]}
+	if !reflect.DeepEqual(actual.Rendered, expectedRendered) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual.Rendered, expectedRendered)
+	}
+	if hash := actual.RenderedHash("main.go"); hash != hashes["main.go"] {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", hash, hashes["main.go"])
+	}
+}
@@ -0,0 +1,553 @@
+package manifest
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/smilepakawat/goat/internal/version"
+	"gopkg.in/yaml.v3"
+)
+
+const FileName = "goat.yaml"
+
+const (
+	TypeString = "string"
+	TypeBool   = "bool"
+	TypeInt    = "int"
+)
+
+// reservedNames are always provided by the generator and cannot be redeclared.
+var reservedNames = []string{"ProjectName", "ModuleName"}
+
+var variableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type Manifest struct {
+	Name           string       `yaml:"name"`
+	Description    string       `yaml:"description"`
+	Version        string       `yaml:"version"`
+	MinGoatVersion string       `yaml:"minGoatVersion"`
+	Extends        Extends      `yaml:"extends"`
+	Abstract       bool         `yaml:"abstract"`
+	Variables      []Variable   `yaml:"variables"`
+	Files          []File       `yaml:"files"`
+	Dependencies   []Dependency `yaml:"dependencies"`
+	Hooks          Hooks        `yaml:"hooks"`
+}
+
+// Extends lists the ids of the templates a template is layered on, earliest
+// first. In goat.yaml it is either a single id or a list.
+type Extends []string
+
+func (e *Extends) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		*e = Extends{node.Value}
+		return nil
+	}
+
+	var ids []string
+	if err := node.Decode(&ids); err != nil {
+		return err
+	}
+	*e = ids
+	return nil
+}
+
+type Variable struct {
+	Name     string   `yaml:"name"`
+	Type     string   `yaml:"type"`
+	Default  any      `yaml:"default"`
+	Pattern  string   `yaml:"pattern"`
+	Choices  []string `yaml:"choices"`
+	Help     string   `yaml:"help"`
+	Required bool     `yaml:"required"`
+}
+
+// File is a template file and its destination relative to the project root.
+// An empty Dest is derived from Src by the generator. In goat.yaml a file is
+// either a plain source path or a {src, dest} mapping. Src may also name a
+// directory, which includes every file below it.
+//
+// When is a template pipeline such as `.Docker` or `ne .Database "none"`; the
+// file is only generated when it is true. Files that render to whitespace
+// only are left out unless KeepEmpty is set.
+type File struct {
+	Src       string `yaml:"src"`
+	Dest      string `yaml:"dest"`
+	When      string `yaml:"when"`
+	KeepEmpty bool   `yaml:"keepEmpty"`
+}
+
+func (f *File) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		f.Src = node.Value
+		return nil
+	}
+
+	type plain File
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*f = File(p)
+	return nil
+}
+
+// Dependency is a module the generated project requires directly. Version is
+// a module query as understood by go get, e.g. v1.10.0, v1, >=v1.9.0 or
+// latest (the default); the newest matching version is resolved when the
+// project is generated. In goat.yaml a dependency is either a mapping or
+// module[@version]. When works like File.When.
+type Dependency struct {
+	Module  string `yaml:"module"`
+	Version string `yaml:"version"`
+	When    string `yaml:"when"`
+}
+
+func (d *Dependency) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		d.Module, d.Version, _ = strings.Cut(node.Value, "@")
+		return nil
+	}
+
+	type plain Dependency
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*d = Dependency(p)
+	return nil
+}
+
+// Query returns the argument go get needs for the dependency.
+func (d Dependency) Query() string {
+	if d.Version == "" {
+		return d.Module + "@latest"
+	}
+	return d.Module + "@" + d.Version
+}
+
+func (d Dependency) validate() error {
+	if d.Module == "" {
+		return errors.New("module is required")
+	}
+	if strings.ContainsAny(d.Module, "@ \t") {
+		return fmt.Errorf("invalid module path %q", d.Module)
+	}
+	if strings.ContainsAny(d.Version, " \t") {
+		return fmt.Errorf("invalid version %q, expected a go get query such as v1.2.3, v1 or latest", d.Version)
+	}
+	return nil
+}
+
+// Built-in hook actions.
+const (
+	ActionGitInit    = "git init"
+	ActionGoModTidy  = "go mod tidy"
+	ActionGofmt      = "gofmt"
+	ActionGoGenerate = "go generate"
+)
+
+var actions = []string{ActionGitInit, ActionGoModTidy, ActionGofmt, ActionGoGenerate}
+
+// Hooks are run in order: Pre before any file is written, in the directory
+// goat runs in, and Post after the project is generated, in the project
+// directory.
+type Hooks struct {
+	Pre  []Hook `yaml:"pre"`
+	Post []Hook `yaml:"post"`
+}
+
+// Hook runs exactly one of Run (a shell command), Go (the arguments of a go
+// subcommand such as "generate ./...") or Action (a built-in action). In
+// goat.yaml a hook is either a mapping or the name of a built-in action.
+//
+// Dir is relative to the directory the hook runs in, Env values and Dir are
+// rendered with the template data. When works like File.When.
+type Hook struct {
+	Name    string            `yaml:"name"`
+	Run     string            `yaml:"run"`
+	Go      string            `yaml:"go"`
+	Action  string            `yaml:"action"`
+	Dir     string            `yaml:"dir"`
+	Env     map[string]string `yaml:"env"`
+	Timeout string            `yaml:"timeout"`
+	When    string            `yaml:"when"`
+}
+
+func (h *Hook) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		h.Action = node.Value
+		return nil
+	}
+
+	type plain Hook
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*h = Hook(p)
+	return nil
+}
+
+// String returns the name of the hook, or its command when it has none.
+func (h Hook) String() string {
+	switch {
+	case h.Name != "":
+		return h.Name
+	case h.Run != "":
+		return h.Run
+	case h.Go != "":
+		return "go " + h.Go
+	}
+	return h.Action
+}
+
+// TimeoutDuration returns the timeout of the hook, zero for none.
+func (h Hook) TimeoutDuration() time.Duration {
+	d, _ := time.ParseDuration(h.Timeout)
+	return d
+}
+
+func (h Hook) validate() error {
+	set := 0
+	for _, command := range []string{h.Run, h.Go, h.Action} {
+		if command != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return errors.New("exactly one of run, go or action is required")
+	}
+	if h.Action != "" && !slices.Contains(actions, h.Action) {
+		return fmt.Errorf("unknown action %q, expected one of %s", h.Action, strings.Join(actions, ", "))
+	}
+	if h.Timeout != "" {
+		if d, err := time.ParseDuration(h.Timeout); err != nil || d <= 0 {
+			return fmt.Errorf("invalid timeout %q, expected a positive duration such as 30s", h.Timeout)
+		}
+	}
+	if path.IsAbs(h.Dir) {
+		return fmt.Errorf("dir %q must be relative", h.Dir)
+	}
+	return nil
+}
+
+func Load(fsys fs.FS, dir string) (Manifest, error) {
+	content, err := fs.ReadFile(fsys, path.Join(dir, FileName))
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	m, err := Parse(content)
+	if err != nil {
+		return Manifest{}, fmt.Errorf("failed to parse manifest %s: %w", path.Join(dir, FileName), err)
+	}
+	return m, nil
+}
+
+func Parse(content []byte) (Manifest, error) {
+	var m Manifest
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&m); err != nil && !errors.Is(err, io.EOF) {
+		return Manifest{}, err
+	}
+
+	if err := m.validate(); err != nil {
+		return Manifest{}, err
+	}
+	return m, nil
+}
+
+func (m Manifest) validate() error {
+	var errs []error
+	if m.Name == "" {
+		errs = append(errs, errors.New("name is required"))
+	}
+	if len(m.Files) == 0 && len(m.Extends) == 0 {
+		errs = append(errs, errors.New("at least one file is required"))
+	}
+	for i, id := range m.Extends {
+		if id == "" {
+			errs = append(errs, fmt.Errorf("extends %d: template id is required", i))
+		}
+	}
+	for i, f := range m.Files {
+		if f.Src == "" {
+			errs = append(errs, fmt.Errorf("file %d: src is required", i))
+		}
+	}
+	for i, d := range m.Dependencies {
+		if err := d.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("dependency %d: %w", i, err))
+		}
+	}
+	for i, h := range m.Hooks.Pre {
+		if err := h.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("pre hook %d: %w", i, err))
+		}
+	}
+	for i, h := range m.Hooks.Post {
+		if err := h.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("post hook %d: %w", i, err))
+		}
+	}
+	if m.MinGoatVersion != "" {
+		if _, err := version.Compare(m.MinGoatVersion, "0"); err != nil {
+			errs = append(errs, fmt.Errorf("minGoatVersion: %w", err))
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, v := range m.Variables {
+		if err := v.validate(); err != nil {
+			errs = append(errs, fmt.Errorf("variable %s%s: %w", v.Name, v.hint(), err))
+		}
+		if seen[v.Name] {
+			errs = append(errs, fmt.Errorf("variable %s: declared more than once", v.Name))
+		}
+		seen[v.Name] = true
+	}
+	return errors.Join(errs...)
+}
+
+func (v Variable) validate() error {
+	if !variableName.MatchString(v.Name) {
+		return errors.New("name must be a valid template identifier")
+	}
+	if slices.Contains(reservedNames, v.Name) {
+		return errors.New("name is reserved")
+	}
+	switch v.Type {
+	case "", TypeString, TypeBool, TypeInt:
+	default:
+		return fmt.Errorf("unknown type %q", v.Type)
+	}
+	if v.Pattern != "" {
+		if _, err := regexp.Compile(v.Pattern); err != nil {
+			return fmt.Errorf("invalid pattern: %w", err)
+		}
+	}
+	if v.Default != nil {
+		if _, err := v.Resolve(v.Default); err != nil {
+			return fmt.Errorf("invalid default: %w", err)
+		}
+	}
+	return nil
+}
+
+// Merge combines the manifests of layered templates, earliest first, into the
+// manifest of the last one. Variables of later layers replace earlier ones
+// with the same name, dependencies on the same module, files and hooks run in
+// layer order and the highest minimum goat version wins.
+func Merge(layers ...Manifest) Manifest {
+	if len(layers) == 0 {
+		return Manifest{}
+	}
+	merged := layers[len(layers)-1]
+	merged.Variables = nil
+	merged.Files = nil
+	merged.Dependencies = nil
+	merged.Hooks = Hooks{}
+	for _, m := range layers {
+		merged.Variables = mergeVariables(merged.Variables, m.Variables)
+		for _, d := range m.Dependencies {
+			if i := slices.IndexFunc(merged.Dependencies, func(existing Dependency) bool { return existing.Module == d.Module }); i >= 0 {
+				merged.Dependencies[i] = d
+			} else {
+				merged.Dependencies = append(merged.Dependencies, d)
+			}
+		}
+		merged.Files = append(merged.Files, m.Files...)
+		merged.Hooks.Pre = append(merged.Hooks.Pre, m.Hooks.Pre...)
+		merged.Hooks.Post = append(merged.Hooks.Post, m.Hooks.Post...)
+		merged.MinGoatVersion = maxGoatVersion(merged.MinGoatVersion, m.MinGoatVersion)
+	}
+	return merged
+}
+
+// WithOverlays adds the files and variables of overlays, such as a company
+// overlay, on top of m. The name, version, dependencies and hooks stay those
+// of m; only the highest minimum goat version is taken over as well.
+func (m Manifest) WithOverlays(overlays ...Manifest) Manifest {
+	m.Variables = slices.Clone(m.Variables)
+	m.Files = slices.Clone(m.Files)
+	for _, o := range overlays {
+		m.Variables = mergeVariables(m.Variables, o.Variables)
+		m.Files = append(m.Files, o.Files...)
+		m.MinGoatVersion = maxGoatVersion(m.MinGoatVersion, o.MinGoatVersion)
+	}
+	return m
+}
+
+// mergeVariables adds vs to variables, replacing the ones with the same name.
+func mergeVariables(variables, vs []Variable) []Variable {
+	for _, v := range vs {
+		if i := slices.IndexFunc(variables, func(existing Variable) bool { return existing.Name == v.Name }); i >= 0 {
+			variables[i] = v
+		} else {
+			variables = append(variables, v)
+		}
+	}
+	return variables
+}
+
+func maxGoatVersion(current, other string) string {
+	if current == "" {
+		return other
+	}
+	if cmp, err := version.Compare(other, current); err == nil && cmp > 0 {
+		return other
+	}
+	return current
+}
+
+// CheckGoatVersion fails when the running goat is older than the manifest requires.
+// A development build, whose version cannot be compared, satisfies any
+// well-formed requirement.
+func (m Manifest) CheckGoatVersion(current string) error {
+	if m.MinGoatVersion == "" {
+		return nil
+	}
+	if _, err := version.Compare(m.MinGoatVersion, "0"); err != nil {
+		return fmt.Errorf("template %s: minGoatVersion: %w", m.Name, err)
+	}
+	cmp, err := version.Compare(current, m.MinGoatVersion)
+	if err != nil {
+		return nil
+	}
+	if cmp < 0 {
+		return fmt.Errorf("template %s requires goat %s or newer, running %s", m.Name, m.MinGoatVersion, current)
+	}
+	return nil
+}
+
+// ResolveVariables validates answers against the declared variables, fills in
+// defaults and returns the values typed as declared. Every problem is reported.
+func (m Manifest) ResolveVariables(answers map[string]any) (map[string]any, error) {
+	var errs []error
+	resolved := make(map[string]any, len(m.Variables))
+
+	for name := range answers {
+		if !slices.ContainsFunc(m.Variables, func(v Variable) bool { return v.Name == name }) {
+			errs = append(errs, fmt.Errorf("unknown variable %s", name))
+		}
+	}
+
+	for _, v := range m.Variables {
+		value, ok := answers[v.Name]
+		if !ok || value == nil {
+			value = v.Default
+		}
+		if value == nil {
+			if v.Required {
+				errs = append(errs, fmt.Errorf("variable %s%s is required", v.Name, v.hint()))
+			} else {
+				resolved[v.Name] = v.zero()
+			}
+			continue
+		}
+
+		typed, err := v.Resolve(value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("variable %s%s: %w", v.Name, v.hint(), err))
+			continue
+		}
+		resolved[v.Name] = typed
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return resolved, nil
+}
+
+// hint describes what the variable expects, for errors about its value.
+func (v Variable) hint() string {
+	var parts []string
+	if v.Help != "" {
+		parts = append(parts, v.Help)
+	}
+	if len(v.Choices) != 0 {
+		parts = append(parts, "one of "+strings.Join(v.Choices, ", "))
+	}
+	if len(parts) == 0 {
+		return ""
+	}
+	return " (" + strings.Join(parts, "; ") + ")"
+}
+
+// Resolve converts a raw answer to the variable type and checks it against
+// the declared pattern and choices.
+func (v Variable) Resolve(value any) (any, error) {
+	var typed any
+	switch v.Type {
+	case "", TypeString:
+		s, ok := value.(string)
+		if !ok {
+			s = fmt.Sprint(value)
+		}
+		typed = s
+	case TypeBool:
+		switch b := value.(type) {
+		case bool:
+			typed = b
+		case string:
+			parsed, err := strconv.ParseBool(b)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not a bool", b)
+			}
+			typed = parsed
+		default:
+			return nil, fmt.Errorf("%v is not a bool", value)
+		}
+	case TypeInt:
+		switch n := value.(type) {
+		case int:
+			typed = n
+		case int64:
+			typed = int(n)
+		case float64:
+			if n != float64(int(n)) {
+				return nil, fmt.Errorf("%v is not an int", n)
+			}
+			typed = int(n)
+		case string:
+			parsed, err := strconv.Atoi(n)
+			if err != nil {
+				return nil, fmt.Errorf("%q is not an int", n)
+			}
+			typed = parsed
+		default:
+			return nil, fmt.Errorf("%v is not an int", value)
+		}
+	}
+
+	str := fmt.Sprint(typed)
+	if len(v.Choices) != 0 && !slices.Contains(v.Choices, str) {
+		return nil, fmt.Errorf("%q is not one of %v", str, v.Choices)
+	}
+	if v.Pattern != "" && !regexp.MustCompile(v.Pattern).MatchString(str) {
+		return nil, fmt.Errorf("%q does not match %s", str, v.Pattern)
+	}
+	return typed, nil
+}
+
+func (v Variable) zero() any {
+	switch v.Type {
+	case TypeBool:
+		return false
+	case TypeInt:
+		return 0
+	}
+	return ""
+}
@@ -0,0 +1,539 @@
+package manifest
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestResolveVariables(t *testing.T) {
+	m := Manifest{
+		Name: "API",
+		Variables: []Variable{
+			{Name: "Port", Type: TypeInt, Default: 8080},
+			{Name: "Docker", Type: TypeBool},
+			{Name: "Database", Choices: []string{"none", "postgres"}, Default: "none"},
+			{Name: "Resource", Pattern: `^[a-z]+$`, Required: true, Help: "Name of the REST resource"},
+			{Name: "Region", Choices: []string{"eu", "us"}, Required: true},
+		},
+	}
+
+	tests := []struct {
+		name          string
+		answers       map[string]any
+		expectedValue map[string]any
+		wantErrors    []string
+	}{
+		{
+			name:    "defaults and typed answers",
+			answers: map[string]any{"Resource": "user", "Region": "eu", "Docker": "true", "Port": "3000"},
+			expectedValue: map[string]any{
+				"Port":     3000,
+				"Docker":   true,
+				"Database": "none",
+				"Resource": "user",
+				"Region":   "eu",
+			},
+		},
+		{
+			name:    "json numbers",
+			answers: map[string]any{"Resource": "user", "Region": "us", "Port": float64(9000)},
+			expectedValue: map[string]any{
+				"Port":     9000,
+				"Docker":   false,
+				"Database": "none",
+				"Resource": "user",
+				"Region":   "us",
+			},
+		},
+		{
+			name:    "every problem is reported",
+			answers: map[string]any{"Port": "http", "Database": "mysql", "Color": "red"},
+			wantErrors: []string{
+				"unknown variable Color",
+				"variable Port",
+				"variable Database (one of none, postgres)",
+				"variable Resource (Name of the REST resource) is required",
+				"variable Region (one of eu, us) is required",
+			},
+		},
+		{
+			name:       "pattern mismatch",
+			answers:    map[string]any{"Resource": "User", "Region": "eu"},
+			wantErrors: []string{"does not match"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := m.ResolveVariables(tt.answers)
+			if len(tt.wantErrors) != 0 {
+				if err == nil {
+					t.Fatalf("ResolveVariables() error = nil, want %v", tt.wantErrors)
+				}
+				for _, want := range tt.wantErrors {
+					if !strings.Contains(err.Error(), want) {
+						t.Errorf("ResolveVariables() error = %v, should contain %q", err, want)
+					}
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveVariables() error = %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestCheckGoatVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		min     string
+		current string
+		wantErr bool
+	}{
+		{name: "no requirement", min: "", current: "0.1.0"},
+		{name: "new enough", min: "0.2.0", current: "0.2.1"},
+		{name: "too old", min: "1.0.0", current: "0.2.0", wantErr: true},
+		{name: "development build", min: "1.0.0", current: "dev"},
+		{name: "malformed requirement", min: "latest", current: "0.2.0", wantErr: true},
+		{name: "malformed requirement on a development build", min: "1.x", current: "dev", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := Manifest{Name: "API", MinGoatVersion: tt.min}
+			err := m.CheckGoatVersion(tt.current)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("CheckGoatVersion() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedValue Manifest
+		wantErr       string
+	}{
+		{
+			name: "valid manifest",
+			content: `name: Fiber
+description: Go Fiber web application
+files:
+  - main.go.tmpl
+  - go.mod.tmpl
+`,
+			expectedValue: Manifest{
+				Name:        "Fiber",
+				Description: "Go Fiber web application",
+				Files:       []File{{Src: "main.go.tmpl"}, {Src: "go.mod.tmpl"}},
+			},
+		},
+		{
+			name: "variables and destinations",
+			content: `name: API
+version: 1.0.0
+minGoatVersion: 0.2.0
+variables:
+  - name: Port
+    type: int
+    default: 8080
+    help: HTTP port
+  - name: Database
+    choices: [none, postgres]
+    default: none
+files:
+  - src: server.go.tmpl
+    dest: cmd/server/main.go
+`,
+			expectedValue: Manifest{
+				Name:           "API",
+				Version:        "1.0.0",
+				MinGoatVersion: "0.2.0",
+				Variables: []Variable{
+					{Name: "Port", Type: TypeInt, Default: 8080, Help: "HTTP port"},
+					{Name: "Database", Choices: []string{"none", "postgres"}, Default: "none"},
+				},
+				Files: []File{{Src: "server.go.tmpl", Dest: "cmd/server/main.go"}},
+			},
+		},
+		{
+			name: "conditional files",
+			content: `name: API
+files:
+  - src: Dockerfile.tmpl
+    when: .Docker
+  - src: internal/db
+    when: ne .Database "none"
+  - src: dot_keep.tmpl
+    keepEmpty: true
+`,
+			expectedValue: Manifest{
+				Name: "API",
+				Files: []File{
+					{Src: "Dockerfile.tmpl", When: ".Docker"},
+					{Src: "internal/db", When: `ne .Database "none"`},
+					{Src: "dot_keep.tmpl", KeepEmpty: true},
+				},
+			},
+		},
+		{
+			name:    "missing name",
+			content: "files: [main.go.tmpl]\n",
+			wantErr: "name is required",
+		},
+		{
+			name:    "missing files",
+			content: "name: Fiber\n",
+			wantErr: "at least one file is required",
+		},
+		{
+			name:    "file without src",
+			content: "name: Fiber\nfiles:\n  - dest: main.go\n",
+			wantErr: "src is required",
+		},
+		{
+			name:    "reserved variable name",
+			content: "name: Fiber\nfiles: [main.go.tmpl]\nvariables:\n  - name: ProjectName\n",
+			wantErr: "reserved",
+		},
+		{
+			name:    "unknown variable type",
+			content: "name: Fiber\nfiles: [main.go.tmpl]\nvariables:\n  - name: Port\n    type: float\n",
+			wantErr: "unknown type",
+		},
+		{
+			name:    "invalid pattern",
+			content: "name: Fiber\nfiles: [main.go.tmpl]\nvariables:\n  - name: Port\n    pattern: \"[\"\n",
+			wantErr: "invalid pattern",
+		},
+		{
+			name:    "default not in choices",
+			content: "name: Fiber\nfiles: [main.go.tmpl]\nvariables:\n  - name: Database\n    choices: [none]\n    default: mysql\n",
+			wantErr: "invalid default",
+		},
+		{
+			name:    "duplicated variable",
+			content: "name: Fiber\nfiles: [main.go.tmpl]\nvariables:\n  - name: Port\n  - name: Port\n",
+			wantErr: "declared more than once",
+		},
+		{
+			name:    "unknown key",
+			content: "name: Fiber\nfiles: [main.go.tmpl]\nauthor: me\n",
+			wantErr: "author",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Parse([]byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("Parse() error = %v, should contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(actual, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/fiber/goat.yaml": {Data: []byte("name: Fiber\nfiles: [main.go.tmpl]\n")},
+	}
+
+	m, err := Load(fsys, "templates/fiber")
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if m.Name != "Fiber" {
+		t.Errorf("Expected name 'Fiber', got '%s'", m.Name)
+	}
+
+	if _, err := Load(fsys, "templates/gin"); err == nil {
+		t.Error("Load() should fail for a directory without manifest")
+	}
+}
+
+func TestParse_Extends(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedValue Extends
+		wantErr       bool
+	}{
+		{
+			name:          "single parent",
+			content:       "name: API\nextends: base\n",
+			expectedValue: Extends{"base"},
+		},
+		{
+			name:          "several parents",
+			content:       "name: API\nextends: [base, docker]\nfiles: [main.go.tmpl]\n",
+			expectedValue: Extends{"base", "docker"},
+		},
+		{
+			name:    "empty parent",
+			content: "name: API\nextends: [\"\"]\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(tt.content))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(m.Extends, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", m.Extends, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestMerge(t *testing.T) {
+	base := Manifest{
+		Name:           "Base",
+		MinGoatVersion: "0.3.0",
+		Abstract:       true,
+		Variables:      []Variable{{Name: "License", Type: "string", Default: "MIT"}, {Name: "CI", Type: "bool"}},
+		Files:          []File{{Src: "dot_gitignore.tmpl"}},
+		Dependencies:   []Dependency{{Module: "github.com/google/uuid"}, {Module: "github.com/gin-gonic/gin", Version: "v1.9"}},
+		Hooks:          Hooks{Post: []Hook{{Action: ActionGoModTidy}}},
+	}
+	api := Manifest{
+		Name:           "API",
+		MinGoatVersion: "0.2.0",
+		Extends:        Extends{"base"},
+		Variables:      []Variable{{Name: "License", Type: "string", Default: "Apache-2.0"}, {Name: "Port", Type: "int"}},
+		Files:          []File{{Src: "main.go.tmpl"}},
+		Dependencies:   []Dependency{{Module: "github.com/gin-gonic/gin", Version: "v1"}},
+		Hooks:          Hooks{Post: []Hook{{Action: ActionGofmt}}},
+	}
+
+	merged := Merge(base, api)
+
+	expected := Manifest{
+		Name:           "API",
+		MinGoatVersion: "0.3.0",
+		Extends:        Extends{"base"},
+		Variables: []Variable{
+			{Name: "License", Type: "string", Default: "Apache-2.0"},
+			{Name: "CI", Type: "bool"},
+			{Name: "Port", Type: "int"},
+		},
+		Files:        []File{{Src: "dot_gitignore.tmpl"}, {Src: "main.go.tmpl"}},
+		Dependencies: []Dependency{{Module: "github.com/google/uuid"}, {Module: "github.com/gin-gonic/gin", Version: "v1"}},
+		Hooks:        Hooks{Post: []Hook{{Action: ActionGoModTidy}, {Action: ActionGofmt}}},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Value not match\nactual = %+v\nexpected = %+v", merged, expected)
+	}
+}
+
+func TestWithOverlays(t *testing.T) {
+	api := Manifest{
+		Name:           "API",
+		Version:        "1.2.0",
+		MinGoatVersion: "0.2.0",
+		Variables:      []Variable{{Name: "License", Type: "string", Default: "MIT"}},
+		Files:          []File{{Src: "main.go.tmpl"}},
+		Dependencies:   []Dependency{{Module: "github.com/gin-gonic/gin"}},
+		Hooks:          Hooks{Post: []Hook{{Action: ActionGoModTidy}}},
+	}
+	company := Manifest{
+		Name:           "Company",
+		Version:        "0.1.0",
+		MinGoatVersion: "0.3.0",
+		Variables:      []Variable{{Name: "License", Type: "string", Default: "Proprietary"}, {Name: "Team", Type: "string"}},
+		Files:          []File{{Src: "CODEOWNERS.tmpl"}},
+		Dependencies:   []Dependency{{Module: "github.com/google/uuid"}},
+		Hooks:          Hooks{Post: []Hook{{Run: "make lint"}}},
+	}
+
+	merged := api.WithOverlays(company)
+
+	expected := Manifest{
+		Name:           "API",
+		Version:        "1.2.0",
+		MinGoatVersion: "0.3.0",
+		Variables: []Variable{
+			{Name: "License", Type: "string", Default: "Proprietary"},
+			{Name: "Team", Type: "string"},
+		},
+		Files:        []File{{Src: "main.go.tmpl"}, {Src: "CODEOWNERS.tmpl"}},
+		Dependencies: []Dependency{{Module: "github.com/gin-gonic/gin"}},
+		Hooks:        Hooks{Post: []Hook{{Action: ActionGoModTidy}}},
+	}
+	if !reflect.DeepEqual(merged, expected) {
+		t.Errorf("Value not match\nactual = %+v\nexpected = %+v", merged, expected)
+	}
+	if api.Variables[0].Default != "MIT" || len(api.Files) != 1 {
+		t.Errorf("WithOverlays() modified the template manifest: %+v", api)
+	}
+}
+
+func TestParse_Hooks(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedValue Hooks
+		wantErr       string
+	}{
+		{
+			name: "actions, shell and go hooks",
+			content: `name: API
+files: [main.go.tmpl]
+hooks:
+  pre:
+    - run: command -v docker
+  post:
+    - go mod tidy
+    - go: generate ./...
+      dir: internal
+      timeout: 2m
+      when: .Generate
+      env:
+        CGO_ENABLED: "0"
+`,
+			expectedValue: Hooks{
+				Pre: []Hook{{Run: "command -v docker"}},
+				Post: []Hook{
+					{Action: ActionGoModTidy},
+					{Go: "generate ./...", Dir: "internal", Timeout: "2m", When: ".Generate", Env: map[string]string{"CGO_ENABLED": "0"}},
+				},
+			},
+		},
+		{
+			name:    "unknown action",
+			content: "name: API\nfiles: [main.go.tmpl]\nhooks:\n  post: [npm install]\n",
+			wantErr: `post hook 0: unknown action "npm install"`,
+		},
+		{
+			name:    "two commands",
+			content: "name: API\nfiles: [main.go.tmpl]\nhooks:\n  pre:\n    - run: make\n      go: vet ./...\n",
+			wantErr: "pre hook 0: exactly one of run, go or action is required",
+		},
+		{
+			name:    "invalid timeout",
+			content: "name: API\nfiles: [main.go.tmpl]\nhooks:\n  post:\n    - run: make\n      timeout: soon\n",
+			wantErr: `invalid timeout "soon"`,
+		},
+		{
+			name:    "absolute dir",
+			content: "name: API\nfiles: [main.go.tmpl]\nhooks:\n  post:\n    - run: make\n      dir: /tmp\n",
+			wantErr: `dir "/tmp" must be relative`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(m.Hooks, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %+v\nexpected = %+v", m.Hooks, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestParse_Dependencies(t *testing.T) {
+	tests := []struct {
+		name          string
+		content       string
+		expectedValue []Dependency
+		wantErr       string
+	}{
+		{
+			name: "mapping and module@version",
+			content: `name: API
+files: [main.go.tmpl]
+dependencies:
+  - module: github.com/gin-gonic/gin
+    version: v1
+  - github.com/jackc/pgx/v5@>=v5.5.0
+  - github.com/google/uuid
+  - module: github.com/redis/go-redis/v9
+    when: .Cache
+`,
+			expectedValue: []Dependency{
+				{Module: "github.com/gin-gonic/gin", Version: "v1"},
+				{Module: "github.com/jackc/pgx/v5", Version: ">=v5.5.0"},
+				{Module: "github.com/google/uuid"},
+				{Module: "github.com/redis/go-redis/v9", When: ".Cache"},
+			},
+		},
+		{
+			name:    "missing module",
+			content: "name: API\nfiles: [main.go.tmpl]\ndependencies:\n  - version: v1\n",
+			wantErr: "dependency 0: module is required",
+		},
+		{
+			name:    "invalid version",
+			content: "name: API\nfiles: [main.go.tmpl]\ndependencies:\n  - module: github.com/gin-gonic/gin\n    version: v1 or v2\n",
+			wantErr: `dependency 0: invalid version "v1 or v2"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := Parse([]byte(tt.content))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Parse() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(m.Dependencies, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %+v\nexpected = %+v", m.Dependencies, tt.expectedValue)
+			}
+		})
+	}
+}
+
+func TestDependency_Query(t *testing.T) {
+	tests := []struct {
+		name          string
+		dependency    Dependency
+		expectedValue string
+	}{
+		{name: "latest by default", dependency: Dependency{Module: "github.com/google/uuid"}, expectedValue: "github.com/google/uuid@latest"},
+		{name: "version query", dependency: Dependency{Module: "github.com/gin-gonic/gin", Version: "v1"}, expectedValue: "github.com/gin-gonic/gin@v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if actual := tt.dependency.Query(); actual != tt.expectedValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/smilepakawat/goat/pkg"
+)
+
+const (
+	PathEnv        = "GOAT_TEMPLATE_PATH"
+	EmbeddedSource = "embedded"
+)
+
+type layer struct {
+	source string
+	fsys   fs.FS
+}
+
+// Overlay merges several template trees with the layout of pkg/templates.
+// Templates are overridden as a whole: the first layer that has a template
+// directory owns it, so a local template never mixes files with an embedded one.
+// Shared directories such as _partials are merged file by file instead.
+type Overlay struct {
+	layers []layer
+}
+
+// NewOverlay stacks the given template directories on top of the embedded
+// templates, highest priority first.
+func NewOverlay(dirs ...string) (*Overlay, error) {
+	overlay := &Overlay{}
+	for _, dir := range dirs {
+		info, err := os.Stat(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open template directory: %w", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("template directory %s is not a directory", dir)
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve template directory %s: %w", dir, err)
+		}
+		overlay.layers = append(overlay.layers, layer{source: abs, fsys: mount(Root, os.DirFS(abs))})
+	}
+	overlay.layers = append(overlay.layers, layer{source: EmbeddedSource, fsys: pkg.Templates})
+	return overlay, nil
+}
+
+// AddTemplate registers the single template in dir under id with the highest
+// priority, e.g. a template fetched from a git repository.
+func (o *Overlay) AddTemplate(id, dir, source string) {
+	l := layer{source: source, fsys: mount(path.Join(Root, id), os.DirFS(dir))}
+	o.layers = append([]layer{l}, o.layers...)
+}
+
+// SearchPath returns the template directories to use: the explicit one first,
+// then every existing entry of GOAT_TEMPLATE_PATH.
+func SearchPath(templateDir string) []string {
+	var dirs []string
+	if templateDir != "" {
+		dirs = append(dirs, templateDir)
+	}
+	for _, dir := range filepath.SplitList(os.Getenv(PathEnv)) {
+		if dir == "" {
+			continue
+		}
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs
+}
+
+func (o *Overlay) Open(name string) (fs.File, error) {
+	l, err := o.layerFor(name)
+	if err != nil {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: err}
+	}
+	return l.fsys.Open(name)
+}
+
+func (o *Overlay) ReadDir(name string) ([]fs.DirEntry, error) {
+	if name != "." && name != Root && !isShared(name) {
+		l, err := o.layerFor(name)
+		if err != nil {
+			return nil, &fs.PathError{Op: "readdir", Path: name, Err: err}
+		}
+		return fs.ReadDir(l.fsys, name)
+	}
+
+	merged := make(map[string]fs.DirEntry)
+	found := false
+	for _, l := range o.layers {
+		entries, err := fs.ReadDir(l.fsys, name)
+		if err != nil {
+			continue
+		}
+		found = true
+		for _, entry := range entries {
+			if _, ok := merged[entry.Name()]; !ok {
+				merged[entry.Name()] = entry
+			}
+		}
+	}
+	if !found {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(merged))
+	for _, entry := range merged {
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name() < entries[j].Name() })
+	return entries, nil
+}
+
+// Source reports where the template owning name comes from: a directory or
+// EmbeddedSource.
+func (o *Overlay) Source(name string) string {
+	l, err := o.layerFor(name)
+	if err != nil {
+		return ""
+	}
+	return l.source
+}
+
+func (o *Overlay) layerFor(name string) (layer, error) {
+	if !fs.ValidPath(name) {
+		return layer{}, fs.ErrInvalid
+	}
+
+	owner := name
+	if rest, ok := strings.CutPrefix(name, Root+"/"); ok && !isShared(name) {
+		id, _, _ := strings.Cut(rest, "/")
+		owner = Root + "/" + id
+	}
+	for _, l := range o.layers {
+		if _, err := fs.Stat(l.fsys, owner); err == nil {
+			return l, nil
+		} else if !errors.Is(err, fs.ErrNotExist) {
+			return layer{}, err
+		}
+	}
+	return layer{}, fs.ErrNotExist
+}
+
+func isShared(name string) bool {
+	return strings.HasPrefix(name, Root+"/"+SharedPrefix)
+}
+
+// mounted exposes fsys under prefix, e.g. a directory laid out like
+// pkg/templates under Root or a single template under Root/<id>.
+type mounted struct {
+	prefix string
+	fsys   fs.FS
+}
+
+func mount(prefix string, fsys fs.FS) fs.FS {
+	return mounted{prefix: prefix, fsys: fsys}
+}
+
+func (m mounted) Open(name string) (fs.File, error) {
+	rel, ok := m.rel(name)
+	if !ok {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+	return m.fsys.Open(rel)
+}
+
+func (m mounted) ReadDir(name string) ([]fs.DirEntry, error) {
+	if rel, ok := m.rel(name); ok {
+		return fs.ReadDir(m.fsys, rel)
+	}
+
+	// Directories above the prefix only contain the next prefix segment.
+	rest, ok := strings.CutPrefix(m.prefix, name+"/")
+	if name == "." {
+		rest, ok = m.prefix, true
+	}
+	if !ok {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: fs.ErrNotExist}
+	}
+	child, _, _ := strings.Cut(rest, "/")
+	return []fs.DirEntry{dirEntry(child)}, nil
+}
+
+func (m mounted) rel(name string) (string, bool) {
+	if !fs.ValidPath(name) {
+		return "", false
+	}
+	if name == m.prefix {
+		return ".", true
+	}
+	return strings.CutPrefix(name, m.prefix+"/")
+}
+
+type dirEntry string
+
+func (d dirEntry) Name() string               { return string(d) }
+func (d dirEntry) IsDir() bool                { return true }
+func (d dirEntry) Type() fs.FileMode          { return fs.ModeDir }
+func (d dirEntry) Info() (fs.FileInfo, error) { return dirInfo(d), nil }
+
+type dirInfo string
+
+func (d dirInfo) Name() string       { return string(d) }
+func (d dirInfo) Size() int64        { return 0 }
+func (d dirInfo) Mode() fs.FileMode  { return fs.ModeDir | 0755 }
+func (d dirInfo) ModTime() time.Time { return time.Time{} }
+func (d dirInfo) IsDir() bool        { return true }
+func (d dirInfo) Sys() any           { return nil }
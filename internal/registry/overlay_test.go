@@ -0,0 +1,194 @@
+package registry
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+	}
+}
+
+func TestOverlay(t *testing.T) {
+	localDir := t.TempDir()
+	writeFiles(t, localDir, map[string]string{
+		"api/goat.yaml":    "name: API\ndescription: company api\nfiles: [main.go.tmpl, ../base/dot_gitignore.tmpl]\n",
+		"api/main.go.tmpl": "package main",
+		"gin/goat.yaml":    "name: Company Gin\nfiles: [main.go.tmpl]\n",
+		"gin/main.go.tmpl": "package main // company",
+		"notes/README.txt": "not a template",
+	})
+
+	overlay, err := NewOverlay(localDir)
+	if err != nil {
+		t.Fatalf("NewOverlay() error = %v", err)
+	}
+	reg, err := Load(overlay)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	expectedIDs := []string{"api", "fiber", "gin"}
+	if !reflect.DeepEqual(reg.IDs(), expectedIDs) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", reg.IDs(), expectedIDs)
+	}
+
+	gin, err := reg.Get("gin")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if gin.Manifest.Name != "Company Gin" {
+		t.Errorf("Local template should override the embedded one, got %s", gin.Manifest.Name)
+	}
+	if gin.Source != localDir {
+		t.Errorf("Expected source %s, got %s", localDir, gin.Source)
+	}
+	if _, err := fs.Stat(overlay, "templates/gin/go.mod.tmpl"); err == nil {
+		t.Error("Embedded files of an overridden template should not be visible")
+	}
+
+	fiber, err := reg.Get("fiber")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if fiber.Source != EmbeddedSource {
+		t.Errorf("Expected source %s, got %s", EmbeddedSource, fiber.Source)
+	}
+
+	// Local templates can still use files of embedded templates.
+	if _, err := fs.ReadFile(overlay, "templates/base/dot_gitignore.tmpl"); err != nil {
+		t.Errorf("Expected embedded base template to be readable: %v", err)
+	}
+	content, err := fs.ReadFile(overlay, "templates/api/main.go.tmpl")
+	if err != nil || string(content) != "package main" {
+		t.Errorf("Expected local template file, got %q, %v", content, err)
+	}
+}
+
+func TestOverlay_SharedDirectories(t *testing.T) {
+	companyDir := t.TempDir()
+	teamDir := t.TempDir()
+	writeFiles(t, companyDir, map[string]string{
+		"_partials/logging.tmpl": `{{define "logging"}}company{{end}}`,
+	})
+	writeFiles(t, teamDir, map[string]string{
+		"_partials/logging.tmpl":  `{{define "logging"}}team{{end}}`,
+		"_partials/shutdown.tmpl": `{{define "graceful_shutdown"}}{{end}}`,
+		"_partials/goat.yaml":     "name: not a template\nfiles: [logging.tmpl]\n",
+	})
+
+	overlay, err := NewOverlay(companyDir, teamDir)
+	if err != nil {
+		t.Fatalf("NewOverlay() error = %v", err)
+	}
+
+	entries, err := fs.ReadDir(overlay, "templates/_partials")
+	if err != nil {
+		t.Fatalf("ReadDir() error = %v", err)
+	}
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	expected := []string{"goat.yaml", "logging.tmpl", "shutdown.tmpl"}
+	if !reflect.DeepEqual(names, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", names, expected)
+	}
+
+	content, err := fs.ReadFile(overlay, "templates/_partials/logging.tmpl")
+	if err != nil || string(content) != `{{define "logging"}}company{{end}}` {
+		t.Errorf("Expected the first layer to win per file, got %q, %v", content, err)
+	}
+	if _, err := fs.ReadFile(overlay, "templates/_partials/shutdown.tmpl"); err != nil {
+		t.Errorf("Expected files of lower layers to be visible: %v", err)
+	}
+
+	reg, err := Load(overlay)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if _, err := reg.Get("_partials"); err == nil {
+		t.Error("Shared directories must not be templates")
+	}
+}
+
+func TestOverlay_InvalidPath(t *testing.T) {
+	overlay, err := NewOverlay()
+	if err != nil {
+		t.Fatalf("NewOverlay() error = %v", err)
+	}
+	if _, err := overlay.Open("templates/fiber/../../../etc/passwd"); err == nil {
+		t.Error("Open() should refuse invalid paths")
+	}
+}
+
+func TestNewOverlay_MissingDirectory(t *testing.T) {
+	if _, err := NewOverlay(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("NewOverlay() should fail for a missing directory")
+	}
+}
+
+func TestSearchPath(t *testing.T) {
+	first := t.TempDir()
+	second := t.TempDir()
+	missing := filepath.Join(t.TempDir(), "missing")
+	t.Setenv(PathEnv, first+string(filepath.ListSeparator)+missing+string(filepath.ListSeparator)+second)
+
+	actual := SearchPath("explicit")
+	expected := []string{"explicit", first, second}
+	if !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, expected)
+	}
+
+	t.Setenv(PathEnv, "")
+	if actual := SearchPath(""); len(actual) != 0 {
+		t.Errorf("Expected empty search path, got %v", actual)
+	}
+}
+
+func TestOverlay_AddTemplate(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"goat.yaml":    "name: Remote Fiber\nfiles: [main.go.tmpl, ../base/dot_gitignore.tmpl]\n",
+		"main.go.tmpl": "package main",
+	})
+
+	overlay, err := NewOverlay()
+	if err != nil {
+		t.Fatalf("NewOverlay() error = %v", err)
+	}
+	overlay.AddTemplate("fiber", dir, "git+file:///srv/templates.git//fiber@v1.0.0")
+
+	reg, err := Load(overlay)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	fiber, err := reg.Get("fiber")
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if fiber.Manifest.Name != "Remote Fiber" {
+		t.Errorf("Added template should override the embedded one, got %s", fiber.Manifest.Name)
+	}
+	if fiber.Source != "git+file:///srv/templates.git//fiber@v1.0.0" {
+		t.Errorf("Unexpected source %s", fiber.Source)
+	}
+	if _, err := reg.Get("gin"); err != nil {
+		t.Errorf("Embedded templates should still be available: %v", err)
+	}
+	if _, err := fs.ReadFile(overlay, "templates/base/dot_gitignore.tmpl"); err != nil {
+		t.Errorf("Expected embedded base template to be readable: %v", err)
+	}
+}
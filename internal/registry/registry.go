@@ -0,0 +1,138 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/smilepakawat/goat/internal/manifest"
+)
+
+const Root = "templates"
+
+// SharedPrefix marks directories that hold files shared by every template,
+// such as _partials. They are never templates themselves.
+const SharedPrefix = "_"
+
+type Template struct {
+	ID       string
+	Dir      string
+	Source   string
+	Manifest manifest.Manifest
+}
+
+type Registry struct {
+	templates map[string]Template
+}
+
+func Load(fsys fs.FS) (*Registry, error) {
+	registry := &Registry{templates: make(map[string]Template)}
+
+	err := fs.WalkDir(fsys, Root, func(dir string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if strings.HasPrefix(d.Name(), SharedPrefix) {
+			return fs.SkipDir
+		}
+		if _, err := fs.Stat(fsys, path.Join(dir, manifest.FileName)); err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		m, err := manifest.Load(fsys, dir)
+		if err != nil {
+			return err
+		}
+		id := strings.TrimPrefix(dir, Root+"/")
+		registry.templates[id] = Template{ID: id, Dir: dir, Source: sourceOf(fsys, dir), Manifest: m}
+		return fs.SkipDir
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load templates: %w", err)
+	}
+
+	return registry, nil
+}
+
+func sourceOf(fsys fs.FS, dir string) string {
+	if s, ok := fsys.(interface{ Source(name string) string }); ok {
+		return s.Source(dir)
+	}
+	return EmbeddedSource
+}
+
+func (r *Registry) Get(id string) (Template, error) {
+	tmpl, ok := r.templates[id]
+	if !ok {
+		return Template{}, fmt.Errorf("unknown template %q, available templates: %s", id, strings.Join(r.IDs(), ", "))
+	}
+	return tmpl, nil
+}
+
+// Layers returns the templates to combine for id, following extends
+// depth-first with the ancestors first, then the overlays on top of it.
+// A template reached twice is only used once.
+func (r *Registry) Layers(id string, overlays ...string) ([]Template, error) {
+	var layers []Template
+	seen := make(map[string]bool)
+	var visit func(id string, path []string) error
+	visit = func(id string, path []string) error {
+		if slices.Contains(path, id) {
+			return fmt.Errorf("template %s extends itself: %s", id, strings.Join(append(path, id), " -> "))
+		}
+		if seen[id] {
+			return nil
+		}
+		tmpl, err := r.Get(id)
+		if err != nil {
+			return err
+		}
+		for _, parent := range tmpl.Manifest.Extends {
+			if err := visit(parent, append(path, id)); err != nil {
+				return err
+			}
+		}
+		seen[id] = true
+		layers = append(layers, tmpl)
+		return nil
+	}
+
+	for _, id := range append([]string{id}, overlays...) {
+		if err := visit(id, nil); err != nil {
+			return nil, err
+		}
+	}
+	return layers, nil
+}
+
+// IDs returns the ids of the templates a project can be created from, i.e.
+// without the abstract ones that only exist to be extended.
+func (r *Registry) IDs() []string {
+	ids := make([]string, 0, len(r.templates))
+	for id, tmpl := range r.templates {
+		if tmpl.Manifest.Abstract {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func (r *Registry) List() []Template {
+	templates := make([]Template, 0, len(r.templates))
+	for _, id := range r.IDs() {
+		templates = append(templates, r.templates[id])
+	}
+	return templates
+}
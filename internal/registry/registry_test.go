@@ -0,0 +1,149 @@
+package registry
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/smilepakawat/goat/pkg"
+)
+
+func TestLoad_EmbeddedTemplates(t *testing.T) {
+	reg, err := Load(pkg.Templates)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	for _, id := range []string{"fiber", "gin"} {
+		tmpl, err := reg.Get(id)
+		if err != nil {
+			t.Errorf("Get(%q) error = %v", id, err)
+			continue
+		}
+		if tmpl.Dir != "templates/"+id {
+			t.Errorf("Expected dir 'templates/%s', got '%s'", id, tmpl.Dir)
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/api/goat.yaml":          {Data: []byte("name: API\nfiles: [main.go.tmpl]\n")},
+		"templates/api/main.go.tmpl":       {Data: []byte("package main")},
+		"templates/company/web/goat.yaml":  {Data: []byte("name: Web\nfiles: [main.go.tmpl]\n")},
+		"templates/company/web/main.tmpl":  {Data: []byte("package main")},
+		"templates/shared/gitignore.tmpl":  {Data: []byte("build/")},
+		"templates/api/nested/goat.yaml":   {Data: []byte("name: Nested\nfiles: [x.tmpl]\n")},
+		"templates/api/nested/other.tmpl":  {Data: []byte("")},
+		"templates/company/web/cmd/x.tmpl": {Data: []byte("")},
+	}
+
+	reg, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	expected := []string{"api", "company/web"}
+	if !reflect.DeepEqual(reg.IDs(), expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", reg.IDs(), expected)
+	}
+}
+
+func TestLoad_InvalidManifest(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/broken/goat.yaml": {Data: []byte("description: no name\n")},
+	}
+
+	if _, err := Load(fsys); err == nil {
+		t.Error("Load() should fail for an invalid manifest")
+	}
+}
+
+func TestGet_UnknownTemplate(t *testing.T) {
+	reg, err := Load(pkg.Templates)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	_, err = reg.Get("rails")
+	if err == nil {
+		t.Fatal("Get() should fail for an unknown template")
+	}
+	if !strings.Contains(err.Error(), "fiber, gin") {
+		t.Errorf("Error should list available templates, got: %v", err)
+	}
+}
+
+func TestLayers(t *testing.T) {
+	fsys := fstest.MapFS{
+		"templates/base/goat.yaml":    {Data: []byte("name: Base\nabstract: true\nfiles: [dot_gitignore.tmpl]\n")},
+		"templates/docker/goat.yaml":  {Data: []byte("name: Docker\nabstract: true\nextends: base\nfiles: [Dockerfile.tmpl]\n")},
+		"templates/api/goat.yaml":     {Data: []byte("name: API\nextends: [base, docker]\nfiles: [main.go.tmpl]\n")},
+		"templates/company/goat.yaml": {Data: []byte("name: Company\nabstract: true\nextends: base\nfiles: [LICENSE.tmpl]\n")},
+		"templates/loop/goat.yaml":    {Data: []byte("name: Loop\nextends: cycle\nfiles: [a.tmpl]\n")},
+		"templates/cycle/goat.yaml":   {Data: []byte("name: Cycle\nextends: loop\nfiles: [b.tmpl]\n")},
+		"templates/orphan/goat.yaml":  {Data: []byte("name: Orphan\nextends: missing\n")},
+	}
+	reg, err := Load(fsys)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		id            string
+		overlays      []string
+		expectedValue []string
+		wantErr       string
+	}{
+		{
+			name:          "ancestors first, each once",
+			id:            "api",
+			expectedValue: []string{"base", "docker", "api"},
+		},
+		{
+			name:          "overlays on top",
+			id:            "api",
+			overlays:      []string{"company"},
+			expectedValue: []string{"base", "docker", "api", "company"},
+		},
+		{
+			name:    "cycle",
+			id:      "loop",
+			wantErr: "loop -> cycle -> loop",
+		},
+		{
+			name:    "unknown parent",
+			id:      "orphan",
+			wantErr: "missing",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layers, err := reg.Layers(tt.id, tt.overlays...)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Layers() error = %v, want it to contain %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Layers() error = %v", err)
+			}
+			var ids []string
+			for _, layer := range layers {
+				ids = append(ids, layer.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", ids, tt.expectedValue)
+			}
+		})
+	}
+
+	expected := []string{"api", "cycle", "loop", "orphan"}
+	if !reflect.DeepEqual(reg.IDs(), expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", reg.IDs(), expected)
+	}
+}
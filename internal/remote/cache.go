@@ -0,0 +1,182 @@
+package remote
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	reposDir     = "repos"
+	checkoutsDir = "checkouts"
+	entrySuffix  = ".json"
+)
+
+// Entry describes one extracted revision in the cache. Hash covers every
+// file of the checkout and is verified before the revision is reused.
+type Entry struct {
+	URL       string    `json:"url"`
+	Ref       string    `json:"ref,omitempty"`
+	Commit    string    `json:"commit"`
+	Hash      string    `json:"hash"`
+	FetchedAt time.Time `json:"fetchedAt"`
+	Dir       string    `json:"-"`
+}
+
+// CacheDir is $XDG_CACHE_HOME/goat, falling back to the platform cache directory.
+func CacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); dir != "" {
+		return filepath.Join(dir, "goat"), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find cache directory: %w", err)
+	}
+	return filepath.Join(dir, "goat"), nil
+}
+
+func List(cacheDir string) ([]Entry, error) {
+	matches, err := filepath.Glob(filepath.Join(cacheDir, checkoutsDir, "*", "*"+entrySuffix))
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]Entry, 0, len(matches))
+	for _, match := range matches {
+		entry, err := readEntry(match)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].URL != entries[j].URL {
+			return entries[i].URL < entries[j].URL
+		}
+		return entries[i].FetchedAt.Before(entries[j].FetchedAt)
+	})
+	return entries, nil
+}
+
+// Clean removes the cached mirror and revisions of url, or the whole cache
+// when url is empty.
+func Clean(cacheDir, url string) error {
+	if url == "" {
+		if err := os.RemoveAll(cacheDir); err != nil {
+			return fmt.Errorf("failed to clean cache: %w", err)
+		}
+		return nil
+	}
+
+	key := cacheKey(url)
+	for _, dir := range []string{
+		filepath.Join(cacheDir, reposDir, key+".git"),
+		filepath.Join(cacheDir, checkoutsDir, key),
+	} {
+		if err := os.RemoveAll(dir); err != nil {
+			return fmt.Errorf("failed to clean cache for %s: %w", url, err)
+		}
+	}
+	return nil
+}
+
+// Verify recomputes the hash of the cached revision and compares it with the
+// recorded one.
+func (e Entry) Verify() error {
+	hash, err := hashDir(e.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", e.Dir, err)
+	}
+	if hash != e.Hash {
+		return fmt.Errorf("cached revision %s of %s is corrupted: hash %s, recorded %s", e.Commit, e.URL, hash, e.Hash)
+	}
+	return nil
+}
+
+func verifyCheckout(checkout string) error {
+	entry, err := readEntry(checkout + entrySuffix)
+	if err != nil {
+		return err
+	}
+	return entry.Verify()
+}
+
+func writeEntry(entry Entry, checkout string) error {
+	hash, err := hashDir(checkout)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %w", checkout, err)
+	}
+	entry.Hash = hash
+
+	content, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := os.WriteFile(checkout+entrySuffix, content, 0644); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+func readEntry(path string) (Entry, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Entry{}, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(content, &entry); err != nil {
+		return Entry{}, fmt.Errorf("failed to parse cache entry %s: %w", path, err)
+	}
+	entry.Dir = path[:len(path)-len(entrySuffix)]
+	return entry, nil
+}
+
+// hashDir hashes the relative path and content of every regular file in dir.
+func hashDir(dir string) (string, error) {
+	if _, err := os.Stat(dir); err != nil {
+		return "", err
+	}
+
+	var files []string
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.Type().IsRegular() {
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	sort.Strings(files)
+
+	sum := sha256.New()
+	for _, file := range files {
+		f, err := os.Open(filepath.Join(dir, filepath.FromSlash(file)))
+		if err != nil {
+			return "", err
+		}
+		fileSum := sha256.New()
+		_, err = io.Copy(fileSum, f)
+		f.Close()
+		if err != nil {
+			return "", err
+		}
+		fmt.Fprintf(sum, "%x  %s\n", fileSum.Sum(nil), file)
+	}
+	return "sha256:" + hex.EncodeToString(sum.Sum(nil)), nil
+}
@@ -0,0 +1,126 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCacheDir(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", "/var/cache/user")
+
+	dir, err := CacheDir()
+	if err != nil {
+		t.Fatalf("CacheDir() error = %v", err)
+	}
+	if dir != filepath.Join("/var/cache/user", "goat") {
+		t.Errorf("Expected cache dir under XDG_CACHE_HOME, got %s", dir)
+	}
+}
+
+func TestListAndVerify(t *testing.T) {
+	repo, first, second := newRepo(t)
+	cacheDir := t.TempDir()
+
+	for _, ref := range []string{"v1.0.0", "main"} {
+		if _, err := Fetch(context.Background(), cacheDir, Spec{URL: "file://" + repo, Ref: ref}); err != nil {
+			t.Fatalf("Fetch() error = %v", err)
+		}
+	}
+
+	entries, err := List(cacheDir)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("Expected 2 cached revisions, got %d", len(entries))
+	}
+	commits := map[string]bool{entries[0].Commit: true, entries[1].Commit: true}
+	if !commits[first] || !commits[second] {
+		t.Errorf("Expected both commits to be cached, got %v", commits)
+	}
+	for _, entry := range entries {
+		if !strings.HasPrefix(entry.Hash, "sha256:") {
+			t.Errorf("Expected sha256 hash, got %s", entry.Hash)
+		}
+		if err := entry.Verify(); err != nil {
+			t.Errorf("Verify() error = %v", err)
+		}
+	}
+
+	// Tampering with a cached file is detected and repaired on the next fetch.
+	tampered := filepath.Join(entries[0].Dir, "api", "main.go.tmpl")
+	if err := os.WriteFile(tampered, []byte("package evil"), 0644); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+	if err := entries[0].Verify(); err == nil {
+		t.Error("Verify() should detect a modified file")
+	}
+	rev, err := Fetch(context.Background(), cacheDir, Spec{URL: "file://" + repo, Subdir: "api", Ref: entries[0].Commit})
+	if err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(rev.Dir, "main.go.tmpl"))
+	if err != nil || strings.Contains(string(content), "evil") {
+		t.Errorf("Expected corrupted revision to be extracted again, got %q, %v", content, err)
+	}
+}
+
+func TestFetch_Offline(t *testing.T) {
+	repo, first, _ := newRepo(t)
+	cacheDir := t.TempDir()
+	spec := Spec{URL: "file://" + repo, Subdir: "api", Ref: "v1.0.0"}
+
+	if _, err := Fetch(context.Background(), cacheDir, spec); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	// Nothing listens on port 1, like a host that cannot be reached.
+	setRemote(t, cacheDir, spec.URL, "http://127.0.0.1:1/templates.git")
+
+	rev, err := Fetch(context.Background(), cacheDir, spec)
+	if err != nil {
+		t.Fatalf("Fetch() should fall back to the cache: %v", err)
+	}
+	if !rev.Offline {
+		t.Error("Expected revision to be marked as offline")
+	}
+	if rev.Commit != first {
+		t.Errorf("Expected commit %s, got %s", first, rev.Commit)
+	}
+
+	if _, err := Fetch(context.Background(), cacheDir, Spec{URL: spec.URL, Ref: "v2.0.0"}); err == nil {
+		t.Error("Fetch() should fail for a ref that is not cached")
+	}
+}
+
+func TestClean(t *testing.T) {
+	repo, _, _ := newRepo(t)
+	cacheDir := t.TempDir()
+	url := "file://" + repo
+
+	if _, err := Fetch(context.Background(), cacheDir, Spec{URL: url}); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if err := Clean(cacheDir, "file:///unknown.git"); err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if entries, _ := List(cacheDir); len(entries) != 1 {
+		t.Errorf("Cleaning another url should keep the cache, got %d entries", len(entries))
+	}
+
+	if err := Clean(cacheDir, url); err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if entries, _ := List(cacheDir); len(entries) != 0 {
+		t.Errorf("Expected empty cache, got %d entries", len(entries))
+	}
+
+	if err := Clean(cacheDir, ""); err != nil {
+		t.Fatalf("Clean() error = %v", err)
+	}
+	if _, err := os.Stat(cacheDir); !os.IsNotExist(err) {
+		t.Errorf("Expected cache directory to be removed, got %v", err)
+	}
+}
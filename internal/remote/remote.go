@@ -0,0 +1,267 @@
+package remote
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/smilepakawat/goat/internal/runner"
+)
+
+const Prefix = "git+"
+
+// Spec identifies a template in a git repository, written as
+// git+<url>[//<subdir>][@<ref>], e.g. git+https://github.com/org/repo//api@v1.2.0.
+type Spec struct {
+	URL    string
+	Subdir string
+	Ref    string
+}
+
+type Revision struct {
+	Spec
+	Commit string
+	Dir    string
+	// Offline is set when the repository could not be refreshed and the
+	// revision was resolved from the cached mirror.
+	Offline bool
+}
+
+func IsRemote(s string) bool {
+	return strings.HasPrefix(s, Prefix)
+}
+
+func ParseSpec(s string) (Spec, error) {
+	rest, ok := strings.CutPrefix(s, Prefix)
+	if !ok {
+		return Spec{}, fmt.Errorf("template source %q must start with %s", s, Prefix)
+	}
+
+	var spec Spec
+	if i := strings.LastIndex(rest, "@"); i > strings.LastIndex(rest, "/") {
+		rest, spec.Ref = rest[:i], rest[i+1:]
+		if spec.Ref == "" {
+			return Spec{}, fmt.Errorf("template source %q has an empty ref", s)
+		}
+	}
+
+	scheme, location, ok := strings.Cut(rest, "://")
+	if !ok || scheme == "" || location == "" {
+		return Spec{}, fmt.Errorf("template source %q is not a URL", s)
+	}
+	// file:///repo has an empty host, skip its leading slash when looking for //subdir.
+	offset := 0
+	if strings.HasPrefix(location, "/") {
+		offset = 1
+	}
+	if i := strings.Index(location[offset:], "//"); i >= 0 {
+		location, spec.Subdir = location[:offset+i], location[offset+i+2:]
+		spec.Subdir = strings.Trim(path.Clean("/"+spec.Subdir), "/")
+	}
+	spec.URL = scheme + "://" + location
+	return spec, nil
+}
+
+func (s Spec) String() string {
+	str := Prefix + s.URL
+	if s.Subdir != "" {
+		str += "//" + s.Subdir
+	}
+	if s.Ref != "" {
+		str += "@" + s.Ref
+	}
+	return str
+}
+
+// ID is the template id the fetched template is registered under.
+func (s Spec) ID() string {
+	if s.Subdir != "" {
+		return path.Base(s.Subdir)
+	}
+	return strings.TrimSuffix(path.Base(s.URL), ".git")
+}
+
+// Fetch mirrors the repository into cacheDir, resolves the ref to a commit
+// and extracts that commit once per revision. When the repository cannot be
+// reached the already mirrored revisions are used, any other failure to
+// update the mirror, e.g. denied access, is returned.
+func Fetch(ctx context.Context, cacheDir string, spec Spec) (Revision, error) {
+	key := cacheKey(spec.URL)
+	repoDir := filepath.Join(cacheDir, reposDir, key+".git")
+
+	offline := false
+	if _, err := os.Stat(repoDir); errors.Is(err, os.ErrNotExist) {
+		if err := os.MkdirAll(filepath.Dir(repoDir), 0755); err != nil {
+			return Revision{}, fmt.Errorf("failed to create cache directory: %w", err)
+		}
+		if _, err := git(ctx, "", "clone", "--mirror", "--quiet", spec.URL, repoDir); err != nil {
+			os.RemoveAll(repoDir)
+			return Revision{}, fmt.Errorf("failed to clone %s: %w", spec.URL, err)
+		}
+	} else if _, err := git(ctx, repoDir, "remote", "update", "--prune"); err != nil {
+		if ctx.Err() != nil {
+			return Revision{}, ctx.Err()
+		}
+		if !isNetworkError(err) {
+			return Revision{}, fmt.Errorf("failed to update %s: %w", spec.URL, err)
+		}
+		offline = true
+	}
+
+	ref := spec.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	out, err := git(ctx, repoDir, "rev-parse", "--verify", "--quiet", ref+"^{commit}")
+	if err != nil {
+		if offline {
+			return Revision{}, fmt.Errorf("failed to fetch %s and %s is not cached", spec.URL, ref)
+		}
+		return Revision{}, fmt.Errorf("failed to resolve %s in %s: %w", ref, spec.URL, err)
+	}
+	commit := strings.TrimSpace(string(out))
+
+	checkout := filepath.Join(cacheDir, checkoutsDir, key, commit)
+	if err := verifyCheckout(checkout); err != nil {
+		os.RemoveAll(checkout)
+		if err := extract(ctx, repoDir, commit, checkout); err != nil {
+			return Revision{}, err
+		}
+		if err := writeEntry(Entry{URL: spec.URL, Ref: spec.Ref, Commit: commit, FetchedAt: time.Now().UTC()}, checkout); err != nil {
+			return Revision{}, err
+		}
+	}
+
+	dir := filepath.Join(checkout, filepath.FromSlash(spec.Subdir))
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return Revision{}, fmt.Errorf("%s has no directory %q at %s", spec.URL, spec.Subdir, commit)
+	}
+	return Revision{Spec: spec, Commit: commit, Dir: dir, Offline: offline}, nil
+}
+
+func extract(ctx context.Context, repoDir, commit, dest string) error {
+	archive, err := git(ctx, repoDir, "archive", "--format=tar", commit)
+	if err != nil {
+		return fmt.Errorf("failed to export %s: %w", commit, err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0755); err != nil {
+		return fmt.Errorf("failed to create checkout directory: %w", err)
+	}
+	staging, err := os.MkdirTemp(filepath.Dir(dest), ".checkout-*")
+	if err != nil {
+		return fmt.Errorf("failed to create checkout directory: %w", err)
+	}
+	defer os.RemoveAll(staging)
+
+	if err := untar(bytes.NewReader(archive), staging); err != nil {
+		return fmt.Errorf("failed to extract %s: %w", commit, err)
+	}
+	if err := os.Rename(staging, dest); err != nil {
+		return fmt.Errorf("failed to store checkout: %w", err)
+	}
+	return nil
+}
+
+func untar(r io.Reader, dest string) error {
+	tr := tar.NewReader(r)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("archive entry %q escapes the checkout", header.Name)
+		}
+		target := filepath.Join(dest, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode)&0755|0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func git(ctx context.Context, gitDir string, args ...string) ([]byte, error) {
+	if gitDir != "" {
+		args = append([]string{"--git-dir", gitDir}, args...)
+	}
+	out, err := runner.Output(ctx, runner.Command{Name: "git", Args: args, Env: []string{"GIT_TERMINAL_PROMPT=0"}})
+	if err != nil {
+		var runErr *runner.Error
+		if errors.As(err, &runErr) && strings.TrimSpace(runErr.Output) != "" {
+			return nil, fmt.Errorf("%w: %s", err, strings.TrimSpace(runErr.Output))
+		}
+		return nil, err
+	}
+	return out, nil
+}
+
+// networkErrors are printed by git when the host of a repository cannot be
+// reached.
+var networkErrors = []string{
+	"could not resolve host",
+	"could not resolve hostname",
+	"temporary failure in name resolution",
+	"failed to connect",
+	"couldn't connect to server",
+	"connection refused",
+	"connection timed out",
+	"connection reset",
+	"operation timed out",
+	"network is unreachable",
+	"no route to host",
+}
+
+// isNetworkError reports whether a failed git command could not reach the
+// remote, as opposed to e.g. an authentication error.
+func isNetworkError(err error) bool {
+	var runErr *runner.Error
+	if !errors.As(err, &runErr) {
+		return false
+	}
+	output := strings.ToLower(runErr.Output)
+	for _, msg := range networkErrors {
+		if strings.Contains(output, msg) {
+			return true
+		}
+	}
+	return false
+}
+
+func cacheKey(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,218 @@
+package remote
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseSpec(t *testing.T) {
+	tests := []struct {
+		name          string
+		input         string
+		expectedValue Spec
+		wantErr       bool
+	}{
+		{
+			name:          "file url with subdir and ref",
+			input:         "git+file:///path/to/repo//subdir@v1.2.0",
+			expectedValue: Spec{URL: "file:///path/to/repo", Subdir: "subdir", Ref: "v1.2.0"},
+		},
+		{
+			name:          "https url with nested subdir",
+			input:         "git+https://github.com/org/templates.git//go/api@main",
+			expectedValue: Spec{URL: "https://github.com/org/templates.git", Subdir: "go/api", Ref: "main"},
+		},
+		{
+			name:          "url without subdir and ref",
+			input:         "git+https://github.com/org/api-template",
+			expectedValue: Spec{URL: "https://github.com/org/api-template"},
+		},
+		{
+			name:          "ssh url with user",
+			input:         "git+ssh://git@github.com/org/templates//api",
+			expectedValue: Spec{URL: "ssh://git@github.com/org/templates", Subdir: "api"},
+		},
+		{
+			name:    "missing prefix",
+			input:   "https://github.com/org/templates",
+			wantErr: true,
+		},
+		{
+			name:    "not a url",
+			input:   "git+templates",
+			wantErr: true,
+		},
+		{
+			name:    "empty ref",
+			input:   "git+https://github.com/org/templates@",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := ParseSpec(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseSpec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(actual, tt.expectedValue) {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectedValue)
+			}
+			if actual.String() != tt.input {
+				t.Errorf("String() = %s, expected %s", actual.String(), tt.input)
+			}
+		})
+	}
+}
+
+func TestSpecID(t *testing.T) {
+	if id := (Spec{URL: "file:///srv/templates.git", Subdir: "go/api"}).ID(); id != "api" {
+		t.Errorf("Expected id 'api', got '%s'", id)
+	}
+	if id := (Spec{URL: "https://github.com/org/service-template.git"}).ID(); id != "service-template" {
+		t.Errorf("Expected id 'service-template', got '%s'", id)
+	}
+}
+
+// newRepo creates a bare repository with an api template tagged v1.0.0 and
+// a newer commit on top, returning the repository path and both commits.
+func newRepo(t *testing.T) (string, string, string) {
+	t.Helper()
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	work := t.TempDir()
+	run := func(args ...string) string {
+		t.Helper()
+		cmd := exec.Command("git", append([]string{"-c", "user.name=goat", "-c", "user.email=goat@example.com"}, args...)...)
+		cmd.Dir = work
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v failed: %v\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(work, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to setup test: %v", err)
+		}
+	}
+
+	run("init", "--quiet", "--initial-branch=main")
+	write("api/goat.yaml", "name: API\nfiles: [main.go.tmpl]\n")
+	write("api/main.go.tmpl", "package main // v1\n")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "v1")
+	run("tag", "-a", "v1.0.0", "-m", "v1.0.0")
+	first := run("rev-parse", "HEAD")
+
+	write("api/main.go.tmpl", "package main // v2\n")
+	run("commit", "--quiet", "-am", "v2")
+	second := run("rev-parse", "HEAD")
+
+	bare := filepath.Join(t.TempDir(), "templates.git")
+	run("clone", "--quiet", "--bare", work, bare)
+	return bare, first, second
+}
+
+func TestFetch(t *testing.T) {
+	repo, first, second := newRepo(t)
+	cacheDir := t.TempDir()
+
+	tests := []struct {
+		name           string
+		ref            string
+		expectedCommit string
+		expectedLine   string
+	}{
+		{name: "tag", ref: "v1.0.0", expectedCommit: first, expectedLine: "v1"},
+		{name: "branch", ref: "main", expectedCommit: second, expectedLine: "v2"},
+		{name: "default branch", ref: "", expectedCommit: second, expectedLine: "v2"},
+		{name: "commit", ref: first[:12], expectedCommit: first, expectedLine: "v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := Spec{URL: "file://" + repo, Subdir: "api", Ref: tt.ref}
+			rev, err := Fetch(context.Background(), cacheDir, spec)
+			if err != nil {
+				t.Fatalf("Fetch() error = %v", err)
+			}
+			if rev.Commit != tt.expectedCommit {
+				t.Errorf("Expected commit %s, got %s", tt.expectedCommit, rev.Commit)
+			}
+			content, err := os.ReadFile(filepath.Join(rev.Dir, "main.go.tmpl"))
+			if err != nil {
+				t.Fatalf("Failed to read fetched template: %v", err)
+			}
+			if !strings.Contains(string(content), tt.expectedLine) {
+				t.Errorf("Expected %s content, got %s", tt.expectedLine, content)
+			}
+			if _, err := os.Stat(filepath.Join(rev.Dir, "goat.yaml")); err != nil {
+				t.Errorf("Expected manifest in fetched template: %v", err)
+			}
+		})
+	}
+}
+
+func TestFetch_Errors(t *testing.T) {
+	repo, _, _ := newRepo(t)
+	cacheDir := t.TempDir()
+
+	tests := []struct {
+		name string
+		spec Spec
+	}{
+		{name: "unknown ref", spec: Spec{URL: "file://" + repo, Subdir: "api", Ref: "v9.9.9"}},
+		{name: "unknown subdir", spec: Spec{URL: "file://" + repo, Subdir: "web"}},
+		{name: "unknown repository", spec: Spec{URL: "file://" + filepath.Join(t.TempDir(), "missing.git")}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := Fetch(context.Background(), cacheDir, tt.spec); err == nil {
+				t.Error("Fetch() should fail")
+			}
+		})
+	}
+}
+
+func TestFetch_UpdateError(t *testing.T) {
+	repo, _, _ := newRepo(t)
+	cacheDir := t.TempDir()
+	spec := Spec{URL: "file://" + repo, Subdir: "api", Ref: "v1.0.0"}
+
+	if _, err := Fetch(context.Background(), cacheDir, spec); err != nil {
+		t.Fatalf("Fetch() error = %v", err)
+	}
+	if err := os.RemoveAll(repo); err != nil {
+		t.Fatalf("Failed to setup test: %v", err)
+	}
+
+	if _, err := Fetch(context.Background(), cacheDir, spec); err == nil || !strings.Contains(err.Error(), "failed to update") {
+		t.Errorf("Fetch() error = %v, want the update error instead of the cached revision", err)
+	}
+}
+
+// setRemote points the mirror of url in cacheDir at another remote.
+func setRemote(t *testing.T, cacheDir, url, remote string) {
+	t.Helper()
+	mirror := filepath.Join(cacheDir, reposDir, cacheKey(url)+".git")
+	if out, err := exec.Command("git", "--git-dir", mirror, "remote", "set-url", "origin", remote).CombinedOutput(); err != nil {
+		t.Fatalf("Failed to setup test: %v\n%s", err, out)
+	}
+}
@@ -0,0 +1,162 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+// outputTail is how much of the output an Error keeps.
+const outputTail = 4096
+
+// waitDelay bounds how long Run waits for the output of a killed command,
+// which children of a shell may keep open.
+const waitDelay = time.Second
+
+// Command is a program to run. Env is added to the environment of goat.
+// Stdout and Stderr default to the ones of goat, point them at a log file
+// to keep the terminal quiet.
+type Command struct {
+	Name    string
+	Args    []string
+	Dir     string
+	Env     []string
+	Timeout time.Duration
+	Stdout  io.Writer
+	Stderr  io.Writer
+}
+
+func (c Command) String() string {
+	return strings.TrimSpace(c.Name + " " + strings.Join(c.Args, " "))
+}
+
+// Error describes a command that could not be started, exited with a non-zero
+// code, timed out or was canceled, e.g. by Ctrl-C.
+type Error struct {
+	Command  string
+	Dir      string
+	ExitCode int
+	TimedOut bool
+	Timeout  time.Duration
+	Canceled bool
+	// Output is the end of what the command wrote to stdout and stderr.
+	Output string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	switch {
+	case e.TimedOut && e.Timeout > 0:
+		return fmt.Sprintf("'%s' timed out after %s", e.Command, e.Timeout)
+	case e.TimedOut:
+		return fmt.Sprintf("'%s' timed out", e.Command)
+	case e.Canceled:
+		return fmt.Sprintf("'%s' was canceled", e.Command)
+	case e.ExitCode > 0:
+		return fmt.Sprintf("'%s' failed with exit code %d", e.Command, e.ExitCode)
+	}
+	return fmt.Sprintf("failed to run '%s': %v", e.Command, e.Err)
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// Run runs c, streaming its output, until it exits, its timeout expires or
+// ctx is done. Failures are returned as *Error.
+func Run(ctx context.Context, c Command) error {
+	return run(ctx, c, true)
+}
+
+// Output runs c like Run and returns what it wrote to stdout instead of
+// streaming it. Stderr is only streamed when c.Stderr is set, the Output of
+// the *Error holds it either way.
+func Output(ctx context.Context, c Command) ([]byte, error) {
+	var stdout bytes.Buffer
+	c.Stdout = &stdout
+	if c.Stderr == nil {
+		c.Stderr = io.Discard
+	}
+	err := run(ctx, c, false)
+	return stdout.Bytes(), err
+}
+
+// run runs c, tailStdout tells whether stdout is kept for the Error next to
+// stderr.
+func run(ctx context.Context, c Command, tailStdout bool) error {
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	tail := &tailWriter{}
+	cmd := exec.CommandContext(ctx, c.Name, c.Args...)
+	cmd.Dir = c.Dir
+	if len(c.Env) != 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+	cmd.Stdout = orDefault(c.Stdout, os.Stdout)
+	if tailStdout {
+		cmd.Stdout = io.MultiWriter(cmd.Stdout, tail)
+	}
+	cmd.Stderr = io.MultiWriter(orDefault(c.Stderr, os.Stderr), tail)
+	cmd.WaitDelay = waitDelay
+
+	err := cmd.Run()
+	if err == nil {
+		return nil
+	}
+
+	runErr := &Error{Command: c.String(), Dir: c.Dir, ExitCode: -1, Output: tail.String(), Err: err}
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		runErr.ExitCode = exitErr.ExitCode()
+	}
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		runErr.TimedOut = true
+		runErr.Timeout = c.Timeout
+		runErr.Err = ctx.Err()
+	case errors.Is(ctx.Err(), context.Canceled):
+		runErr.Canceled = true
+		runErr.Err = ctx.Err()
+	}
+	return runErr
+}
+
+func orDefault(w, def io.Writer) io.Writer {
+	if w == nil {
+		return def
+	}
+	return w
+}
+
+// tailWriter keeps the last outputTail bytes written to it.
+type tailWriter struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+func (t *tailWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > outputTail {
+		t.buf = t.buf[len(t.buf)-outputTail:]
+	}
+	return len(p), nil
+}
+
+func (t *tailWriter) String() string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return string(t.buf)
+}
@@ -0,0 +1,143 @@
+package runner
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands in this test use sh")
+	}
+
+	tests := []struct {
+		name           string
+		command        Command
+		expectedStdout string
+		expectedStderr string
+		expectedErr    *Error
+	}{
+		{
+			name:           "streams stdout and stderr",
+			command:        Command{Name: "sh", Args: []string{"-c", "echo out; echo err >&2"}},
+			expectedStdout: "out\n",
+			expectedStderr: "err\n",
+		},
+		{
+			name:           "environment and directory",
+			command:        Command{Name: "sh", Args: []string{"-c", `echo "$GREETING $PWD"`}, Dir: "/", Env: []string{"GREETING=hello"}},
+			expectedStdout: "hello /\n",
+		},
+		{
+			name:           "exit code",
+			command:        Command{Name: "sh", Args: []string{"-c", "echo broken; exit 3"}},
+			expectedStdout: "broken\n",
+			expectedErr:    &Error{Command: "sh -c echo broken; exit 3", ExitCode: 3, Output: "broken\n"},
+		},
+		{
+			name:        "timeout",
+			command:     Command{Name: "sleep", Args: []string{"5"}, Timeout: 50 * time.Millisecond},
+			expectedErr: &Error{Command: "sleep 5", ExitCode: -1, TimedOut: true, Timeout: 50 * time.Millisecond},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			tt.command.Stdout = &stdout
+			tt.command.Stderr = &stderr
+
+			err := Run(context.Background(), tt.command)
+			if stdout.String() != tt.expectedStdout {
+				t.Errorf("Value not match\nactual = %q\nexpected = %q", stdout.String(), tt.expectedStdout)
+			}
+			if stderr.String() != tt.expectedStderr {
+				t.Errorf("Value not match\nactual = %q\nexpected = %q", stderr.String(), tt.expectedStderr)
+			}
+			if tt.expectedErr == nil {
+				if err != nil {
+					t.Fatalf("Run() error = %v", err)
+				}
+				return
+			}
+
+			var runErr *Error
+			if !errors.As(err, &runErr) {
+				t.Fatalf("Run() error = %v, want *Error", err)
+			}
+			actual := *runErr
+			actual.Err = nil
+			if actual != *tt.expectedErr {
+				t.Errorf("Value not match\nactual = %+v\nexpected = %+v", actual, *tt.expectedErr)
+			}
+		})
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands in this test use sleep")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	err := Run(ctx, Command{Name: "sleep", Args: []string{"5"}})
+	var runErr *Error
+	if !errors.As(err, &runErr) || !runErr.Canceled {
+		t.Fatalf("Run() error = %v, want a canceled *Error", err)
+	}
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected the error to wrap context.Canceled, got %v", err)
+	}
+}
+
+func TestRun_NotFound(t *testing.T) {
+	err := Run(context.Background(), Command{Name: "goat-no-such-command"})
+	if !errors.Is(err, exec.ErrNotFound) {
+		t.Fatalf("Run() error = %v, want exec.ErrNotFound", err)
+	}
+	if !strings.Contains(err.Error(), "failed to run 'goat-no-such-command'") {
+		t.Errorf("Unexpected message: %v", err)
+	}
+}
+
+func TestOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("commands in this test use sh")
+	}
+
+	out, err := Output(context.Background(), Command{Name: "sh", Args: []string{"-c", "echo out; echo err >&2"}})
+	if err != nil {
+		t.Fatalf("Output() error = %v", err)
+	}
+	if string(out) != "out\n" {
+		t.Errorf("Value not match\nactual = %q\nexpected = %q", out, "out\n")
+	}
+
+	out, err = Output(context.Background(), Command{Name: "sh", Args: []string{"-c", "echo partial; echo fatal: broken >&2; exit 128"}})
+	var runErr *Error
+	if !errors.As(err, &runErr) {
+		t.Fatalf("Output() error = %v, want *Error", err)
+	}
+	if string(out) != "partial\n" || runErr.ExitCode != 128 || runErr.Output != "fatal: broken\n" {
+		t.Errorf("Output() = %q, %+v, want stdout separate from the stderr in the error", out, runErr)
+	}
+}
+
+func TestTailWriter(t *testing.T) {
+	tail := &tailWriter{}
+	tail.Write(bytes.Repeat([]byte("a"), outputTail))
+	tail.Write([]byte("end"))
+
+	actual := tail.String()
+	if len(actual) != outputTail || !strings.HasSuffix(actual, "end") {
+		t.Errorf("Expected the last %d bytes ending in 'end', got %d bytes", outputTail, len(actual))
+	}
+}
@@ -2,21 +2,29 @@ package ui
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/smilepakawat/goat/internal/manifest"
 )
 
 const (
 	inputProjectName int = iota
 	inputModuleName
 	done
+	inputVariable
 )
 
 type Model struct {
 	State        int
 	ProjectInput textinput.Model
 	ModuleInput  textinput.Model
+
+	// Variables are asked after the module path, one at a time.
+	Variables      []manifest.Variable
+	VariableInputs []textinput.Model
+	Current        int
 }
 
 func NewInitModel() Model {
@@ -35,6 +43,32 @@ func NewInitModel() Model {
 	}
 }
 
+// AddVariables asks for the template variables after the module path. The
+// default of a variable is used when its answer is left empty.
+func (m *Model) AddVariables(variables ...manifest.Variable) {
+	for _, v := range variables {
+		vi := textinput.New()
+		vi.Focus()
+		vi.Width = 30
+		if v.Default != nil {
+			vi.Placeholder = fmt.Sprint(v.Default)
+		}
+		m.Variables = append(m.Variables, v)
+		m.VariableInputs = append(m.VariableInputs, vi)
+	}
+}
+
+// Answers returns the variables that were answered.
+func (m Model) Answers() map[string]any {
+	answers := make(map[string]any)
+	for i, v := range m.Variables {
+		if value := strings.TrimSpace(m.VariableInputs[i].Value()); value != "" {
+			answers[v.Name] = value
+		}
+	}
+	return answers
+}
+
 func (m Model) Init() tea.Cmd {
 	return textinput.Blink
 }
@@ -59,6 +93,11 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			case "ctrl+c":
 				return m, tea.Quit
 			case "enter":
+				if len(m.Variables) != 0 {
+					m.State = inputVariable
+					m.Current = 0
+					return m, nil
+				}
 				m.State = done
 				return m, tea.Quit
 			default:
@@ -66,6 +105,21 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.ModuleInput, cmd = m.ModuleInput.Update(msg)
 				return m, cmd
 			}
+		case inputVariable:
+			switch msg.String() {
+			case "ctrl+c":
+				return m, tea.Quit
+			case "enter":
+				if m.Current++; m.Current < len(m.Variables) {
+					return m, nil
+				}
+				m.State = done
+				return m, tea.Quit
+			default:
+				var cmd tea.Cmd
+				m.VariableInputs[m.Current], cmd = m.VariableInputs[m.Current].Update(msg)
+				return m, cmd
+			}
 		}
 	}
 	return m, nil
@@ -77,6 +131,27 @@ func (m Model) View() string {
 		return fmt.Sprintf("Project name:\n%s\n\n(press Enter)", m.ProjectInput.View())
 	case inputModuleName:
 		return fmt.Sprintf("Module path:\n%s\n\n(press Enter)", m.ModuleInput.View())
+	case inputVariable:
+		return variableView(m.Variables[m.Current], m.VariableInputs[m.Current])
 	}
 	return ""
 }
+
+func variableView(v manifest.Variable, input textinput.Model) string {
+	var sb strings.Builder
+	sb.WriteString(v.Name)
+	if v.Help != "" {
+		fmt.Fprintf(&sb, " (%s)", v.Help)
+	}
+	sb.WriteString(":\n")
+	if len(v.Choices) != 0 {
+		fmt.Fprintf(&sb, "One of %s\n", strings.Join(v.Choices, ", "))
+	}
+	sb.WriteString(input.View())
+	if v.Default != nil {
+		fmt.Fprintf(&sb, "\n\n(press Enter, empty for %v)", v.Default)
+	} else {
+		sb.WriteString("\n\n(press Enter)")
+	}
+	return sb.String()
+}
@@ -1,9 +1,12 @@
 package ui
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/smilepakawat/goat/internal/manifest"
 )
 
 func TestNewInitModel(t *testing.T) {
@@ -339,6 +342,46 @@ func TestModelFields(t *testing.T) {
 	}
 }
 
+func TestVariables(t *testing.T) {
+	model := NewInitModel()
+	model.AddVariables(
+		manifest.Variable{Name: "Port", Type: manifest.TypeInt, Default: 8080, Help: "Port the HTTP server listens on"},
+		manifest.Variable{Name: "Database", Choices: []string{"none", "postgres"}},
+	)
+	model.State = inputModuleName
+
+	enterKey := tea.KeyMsg{Type: tea.KeyEnter}
+	newModel, cmd := model.Update(enterKey)
+	model = newModel.(Model)
+	if model.State != inputVariable || cmd != nil {
+		t.Fatalf("Expected to advance to inputVariable state, got %d", model.State)
+	}
+	if view := model.View(); !strings.Contains(view, "Port (Port the HTTP server listens on):") || !strings.Contains(view, "empty for 8080") {
+		t.Errorf("Expected help and default in the view, got: %s", view)
+	}
+
+	// Leave Port empty to take its default.
+	newModel, _ = model.Update(enterKey)
+	model = newModel.(Model)
+	if view := model.View(); !strings.Contains(view, "One of none, postgres") {
+		t.Errorf("Expected choices in the view, got: %s", view)
+	}
+	for _, char := range "postgres" {
+		newModel, _ = model.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{char}})
+		model = newModel.(Model)
+	}
+	newModel, cmd = model.Update(enterKey)
+	model = newModel.(Model)
+	if model.State != done || cmd == nil {
+		t.Errorf("Expected to quit in done state, got %d", model.State)
+	}
+
+	expected := map[string]any{"Database": "postgres"}
+	if actual := model.Answers(); !reflect.DeepEqual(actual, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, expected)
+	}
+}
+
 // Benchmark tests
 func BenchmarkNewInitModel(b *testing.B) {
 	for i := 0; i < b.N; i++ {
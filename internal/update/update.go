@@ -0,0 +1,284 @@
+package update
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/smilepakawat/goat/internal/diff"
+	"github.com/smilepakawat/goat/internal/generator"
+	"github.com/smilepakawat/goat/internal/lock"
+	"github.com/smilepakawat/goat/internal/runner"
+)
+
+// RejectSuffix is appended to the path of a file whose template changes
+// could not be merged when rejects are requested instead of conflict markers.
+const RejectSuffix = ".rej"
+
+type Action string
+
+const (
+	Created  Action = "created"
+	Updated  Action = "updated"
+	Merged   Action = "merged"
+	Conflict Action = "conflict"
+	Rejected Action = "rejected"
+	Deleted  Action = "deleted"
+	Kept     Action = "kept"
+)
+
+type Change struct {
+	Path   string
+	Action Action
+	Note   string
+}
+
+type Options struct {
+	// Reject leaves conflicting files untouched and writes the template
+	// changes to <file>.rej instead of merging them with conflict markers.
+	Reject bool
+	DryRun bool
+}
+
+// Apply brings the project in dir from the base rendering of its template to
+// the next one with a three-way merge against the files on disk. base is nil
+// when the recorded revision cannot be rendered anymore, the file hashes of
+// the recorded lockfile then tell untouched files from edited ones and its
+// rendered hashes whether the template changed a file.
+func Apply(ctx context.Context, dir string, base, next []generator.RenderedFile, recorded lock.Lock, opts Options) ([]Change, error) {
+	baseFiles := contents(base)
+	nextFiles := contents(next)
+
+	paths := make(map[string]bool)
+	for p := range nextFiles {
+		paths[p] = true
+	}
+	if base != nil {
+		for p := range baseFiles {
+			paths[p] = true
+		}
+	} else {
+		for p := range recorded.Files {
+			paths[p] = true
+		}
+	}
+	delete(paths, lock.FileName)
+
+	var changes []Change
+	var untouched []string
+	for _, p := range sortedKeys(paths) {
+		change, err := apply(ctx, dir, p, file{base, baseFiles, recorded.Files[p], recorded.RenderedHash(p)}, nextFiles, opts)
+		if err != nil {
+			return changes, err
+		}
+		if change.Action != "" {
+			changes = append(changes, change)
+		} else if content, ok := nextFiles[p]; ok && lock.Hash(content) == recorded.RenderedHash(p) {
+			untouched = append(untouched, p)
+		}
+	}
+
+	if content, ok := nextFiles[lock.FileName]; ok && !opts.DryRun {
+		content, err := keepRecorded(content, recorded, untouched)
+		if err != nil {
+			return changes, err
+		}
+		if err := os.WriteFile(filepath.Join(dir, lock.FileName), content, 0644); err != nil {
+			return changes, fmt.Errorf("failed to write lockfile: %w", err)
+		}
+	}
+	return changes, nil
+}
+
+// keepRecorded takes the recorded hashes of files the template did not
+// change over into the new lockfile, so files goat changed after rendering,
+// such as go.mod after go get, still count as generated.
+func keepRecorded(content []byte, recorded lock.Lock, paths []string) ([]byte, error) {
+	next, err := lock.Decode(content)
+	if err != nil {
+		return nil, err
+	}
+	kept := false
+	for _, p := range paths {
+		if _, ok := next.Files[p]; !ok || recorded.Files[p] == next.Files[p] {
+			continue
+		}
+		next.Files[p] = recorded.Files[p]
+		if hash, ok := recorded.Rendered[p]; ok {
+			if next.Rendered == nil {
+				next.Rendered = make(map[string]string)
+			}
+			next.Rendered[p] = hash
+		}
+		kept = true
+	}
+	if !kept {
+		return content, nil
+	}
+	return lock.Encode(next)
+}
+
+// file is what is known about the previous rendering of a path: the rendered
+// files of the recorded revision, or the hashes of the recorded lockfile when
+// it cannot be rendered anymore. hash is the one of the file as goat left it,
+// rendered the one of the file as it was rendered.
+type file struct {
+	base     []generator.RenderedFile
+	contents map[string][]byte
+	hash     string
+	rendered string
+}
+
+func (f file) known(p string) bool {
+	if f.base != nil {
+		_, ok := f.contents[p]
+		return ok
+	}
+	return f.rendered != ""
+}
+
+// renders tells whether the previous revision rendered p as content.
+func (f file) renders(p string, content []byte) bool {
+	if f.base != nil {
+		old, ok := f.contents[p]
+		return ok && bytes.Equal(old, content)
+	}
+	return f.rendered != "" && lock.Hash(content) == f.rendered
+}
+
+// unchanged tells whether content on disk is still as goat left it.
+func (f file) unchanged(p string, content []byte) bool {
+	if f.base != nil {
+		return f.renders(p, content)
+	}
+	return f.hash != "" && lock.Hash(content) == f.hash
+}
+
+func apply(ctx context.Context, dir, p string, previous file, nextFiles map[string][]byte, opts Options) (Change, error) {
+	target := filepath.Join(dir, filepath.FromSlash(p))
+	ours, err := os.ReadFile(target)
+	exists := err == nil
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Change{}, fmt.Errorf("failed to read %s: %w", target, err)
+	}
+	next, inNext := nextFiles[p]
+
+	switch {
+	case !inNext:
+		if !exists {
+			return Change{}, nil
+		}
+		if previous.unchanged(p, ours) {
+			if !opts.DryRun {
+				if err := os.Remove(target); err != nil {
+					return Change{}, fmt.Errorf("failed to remove %s: %w", target, err)
+				}
+			}
+			return Change{Path: p, Action: Deleted}, nil
+		}
+		return Change{Path: p, Action: Kept, Note: "removed from the template but changed locally"}, nil
+
+	case !exists:
+		if !previous.known(p) {
+			return Change{Path: p, Action: Created}, write(target, next, opts)
+		}
+		if previous.renders(p, next) {
+			return Change{}, nil
+		}
+		return Change{Path: p, Action: Kept, Note: "changed in the template but deleted locally"}, nil
+
+	case bytes.Equal(ours, next), previous.renders(p, next):
+		return Change{}, nil
+
+	case previous.unchanged(p, ours):
+		return Change{Path: p, Action: Updated}, write(target, next, opts)
+	}
+
+	baseContent := previous.contents[p]
+	merged, conflicts, err := merge(ctx, p, ours, baseContent, next)
+	if err != nil {
+		return Change{}, err
+	}
+	if !conflicts {
+		return Change{Path: p, Action: Merged}, write(target, merged, opts)
+	}
+	if opts.Reject {
+		from, label := baseContent, "template"
+		if previous.base == nil {
+			from, label = ours, "local"
+		}
+		reject := diff.Unified(p+" ("+label+")", p+" (new template)", from, next)
+		return Change{Path: p, Action: Rejected, Note: "template changes written to " + p + RejectSuffix}, write(target+RejectSuffix, []byte(reject), opts)
+	}
+	return Change{Path: p, Action: Conflict, Note: "resolve the conflict markers"}, write(target, merged, opts)
+}
+
+// merge runs git merge-file on the local, base and new template version of
+// a file. conflicts is set when the result contains conflict markers.
+func merge(ctx context.Context, p string, ours, base, theirs []byte) (merged []byte, conflicts bool, err error) {
+	tmp, err := os.MkdirTemp("", "goat-merge-*")
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create merge directory: %w", err)
+	}
+	defer os.RemoveAll(tmp)
+
+	names := []string{"local", "base", "template"}
+	args := []string{"merge-file", "-p"}
+	for i, content := range [][]byte{ours, base, theirs} {
+		args = append(args, "-L", p+" ("+names[i]+")")
+		if err := os.WriteFile(filepath.Join(tmp, names[i]), content, 0644); err != nil {
+			return nil, false, fmt.Errorf("failed to prepare merge of %s: %w", p, err)
+		}
+	}
+	for _, name := range names {
+		args = append(args, filepath.Join(tmp, name))
+	}
+
+	out, err := runner.Output(ctx, runner.Command{Name: "git", Args: args})
+	if err == nil {
+		return out, false, nil
+	}
+	var runErr *runner.Error
+	if errors.As(err, &runErr) && runErr.ExitCode > 0 && runErr.ExitCode < 128 {
+		return out, true, nil
+	}
+	if runErr != nil && strings.TrimSpace(runErr.Output) != "" {
+		return nil, false, fmt.Errorf("failed to merge %s: %w: %s", p, err, strings.TrimSpace(runErr.Output))
+	}
+	return nil, false, fmt.Errorf("failed to merge %s: %w", p, err)
+}
+
+func write(path string, content []byte, opts Options) error {
+	if opts.DryRun {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", path, err)
+	}
+	if err := os.WriteFile(path, content, 0666); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+func contents(files []generator.RenderedFile) map[string][]byte {
+	res := make(map[string][]byte, len(files))
+	for _, f := range files {
+		res[f.Path] = f.Content
+	}
+	return res
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
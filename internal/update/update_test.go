@@ -0,0 +1,224 @@
+package update
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/smilepakawat/goat/internal/generator"
+	"github.com/smilepakawat/goat/internal/lock"
+)
+
+func writeProject(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func rendered(files map[string]string) []generator.RenderedFile {
+	res := make([]generator.RenderedFile, 0, len(files))
+	for name, content := range files {
+		res = append(res, generator.RenderedFile{Path: name, Content: []byte(content)})
+	}
+	return res
+}
+
+func readFile(t *testing.T, dir, name string) string {
+	t.Helper()
+	content, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		t.Fatalf("Failed to read %s: %v", name, err)
+	}
+	return string(content)
+}
+
+func TestApply(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	base := map[string]string{
+		"pristine.go": "package main\n",
+		"edited.go":   "a\nb\nc\nd\ne\nf\ng\n",
+		"clash.go":    "port = 8080\n",
+		"same.go":     "package same\n",
+		"removed.go":  "package removed\n",
+		"custom.go":   "package custom\n",
+	}
+	next := map[string]string{
+		"pristine.go": "package main\n\nfunc main() {}\n",
+		"edited.go":   "a\nb\nc\nd\ne\nf\nG\n",
+		"clash.go":    "port = 9090\n",
+		"same.go":     "package same\n",
+		"new.go":      "package new\n",
+		lock.FileName: "goatVersion: 0.2.0\n",
+	}
+	disk := map[string]string{
+		"pristine.go": "package main\n",
+		"edited.go":   "A\nb\nc\nd\ne\nf\ng\n",
+		"clash.go":    "port = 3000\n",
+		"same.go":     "package same // edited\n",
+		"removed.go":  "package removed\n",
+		"custom.go":   "package custom // edited\n",
+	}
+
+	dir := writeProject(t, disk)
+	changes, err := Apply(context.Background(), dir, rendered(base), rendered(next), lock.Lock{}, Options{})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	expected := []Change{
+		{Path: "clash.go", Action: Conflict, Note: "resolve the conflict markers"},
+		{Path: "custom.go", Action: Kept, Note: "removed from the template but changed locally"},
+		{Path: "edited.go", Action: Merged},
+		{Path: "new.go", Action: Created},
+		{Path: "pristine.go", Action: Updated},
+		{Path: "removed.go", Action: Deleted},
+	}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", changes, expected)
+	}
+
+	if actual := readFile(t, dir, "edited.go"); actual != "A\nb\nc\nd\ne\nf\nG\n" {
+		t.Errorf("Value not match\nactual = %q\nexpected = %q", actual, "A\nb\nc\nd\ne\nf\nG\n")
+	}
+	if actual := readFile(t, dir, "clash.go"); !strings.Contains(actual, "<<<<<<< clash.go (local)") || !strings.Contains(actual, ">>>>>>> clash.go (template)") {
+		t.Errorf("Expected conflict markers, got %q", actual)
+	}
+	if actual := readFile(t, dir, "same.go"); actual != disk["same.go"] {
+		t.Errorf("Local edits of a file the template did not change must be kept, got %q", actual)
+	}
+	if actual := readFile(t, dir, "pristine.go"); actual != next["pristine.go"] {
+		t.Errorf("Value not match\nactual = %q\nexpected = %q", actual, next["pristine.go"])
+	}
+	if _, err := os.Stat(filepath.Join(dir, "removed.go")); !os.IsNotExist(err) {
+		t.Errorf("Expected removed.go to be deleted, got %v", err)
+	}
+	if actual := readFile(t, dir, lock.FileName); actual != next[lock.FileName] {
+		t.Errorf("Expected the lockfile to be rewritten, got %q", actual)
+	}
+}
+
+func TestApply_Reject(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	dir := writeProject(t, map[string]string{"clash.go": "port = 3000\n"})
+	changes, err := Apply(context.Background(), dir,
+		rendered(map[string]string{"clash.go": "port = 8080\n"}),
+		rendered(map[string]string{"clash.go": "port = 9090\n"}),
+		lock.Lock{}, Options{Reject: true})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	expected := []Change{{Path: "clash.go", Action: Rejected, Note: "template changes written to clash.go.rej"}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", changes, expected)
+	}
+	if actual := readFile(t, dir, "clash.go"); actual != "port = 3000\n" {
+		t.Errorf("Rejected file must be left untouched, got %q", actual)
+	}
+	expectedReject := "--- clash.go (template)\n+++ clash.go (new template)\n@@ -1 +1 @@\n-port = 8080\n+port = 9090\n"
+	if actual := readFile(t, dir, "clash.go.rej"); actual != expectedReject {
+		t.Errorf("Value not match\nactual = %q\nexpected = %q", actual, expectedReject)
+	}
+}
+
+func TestApply_DryRun(t *testing.T) {
+	dir := writeProject(t, map[string]string{"main.go": "package main\n"})
+	changes, err := Apply(context.Background(), dir,
+		rendered(map[string]string{"main.go": "package main\n"}),
+		rendered(map[string]string{"main.go": "package main // v2\n", "new.go": "package new\n"}),
+		lock.Lock{}, Options{DryRun: true})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	expected := []Change{{Path: "main.go", Action: Updated}, {Path: "new.go", Action: Created}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", changes, expected)
+	}
+	if actual := readFile(t, dir, "main.go"); actual != "package main\n" {
+		t.Errorf("Dry run must not write, got %q", actual)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "new.go")); !os.IsNotExist(err) {
+		t.Errorf("Dry run must not create files, got %v", err)
+	}
+}
+
+func TestApply_WithoutBase(t *testing.T) {
+	dir := writeProject(t, map[string]string{
+		"pristine.go": "package main\n",
+		"edited.go":   "package edited // mine\n",
+	})
+	recorded := lock.Lock{Files: map[string]string{
+		"pristine.go": lock.Hash([]byte("package main\n")),
+		"edited.go":   lock.Hash([]byte("package edited\n")),
+	}}
+
+	changes, err := Apply(context.Background(), dir, nil,
+		rendered(map[string]string{"pristine.go": "package main // v2\n", "edited.go": "package edited\n"}),
+		recorded, Options{})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	expected := []Change{{Path: "pristine.go", Action: Updated}}
+	if !reflect.DeepEqual(changes, expected) {
+		t.Errorf("Value not match\nactual = %v\nexpected = %v", changes, expected)
+	}
+	if actual := readFile(t, dir, "edited.go"); actual != "package edited // mine\n" {
+		t.Errorf("Local edits must be kept, got %q", actual)
+	}
+}
+
+func TestApply_ChangedAfterRendering(t *testing.T) {
+	gomod := "module svc\n\ngo 1.22\n"
+	hooked := gomod + "\nrequire example.com/dep v1.0.0\n"
+	dir := writeProject(t, map[string]string{"go.mod": hooked})
+	recorded := lock.Lock{
+		Files:    map[string]string{"go.mod": lock.Hash([]byte(hooked))},
+		Rendered: map[string]string{"go.mod": lock.Hash([]byte(gomod))},
+	}
+	nextLock, err := lock.Encode(lock.Lock{Files: map[string]string{"go.mod": lock.Hash([]byte(gomod))}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	changes, err := Apply(context.Background(), dir, nil,
+		rendered(map[string]string{"go.mod": gomod, lock.FileName: string(nextLock)}),
+		recorded, Options{})
+	if err != nil {
+		t.Fatalf("Apply() error = %v", err)
+	}
+
+	if len(changes) != 0 {
+		t.Errorf("Expected no changes, got %v", changes)
+	}
+	if actual := readFile(t, dir, "go.mod"); actual != hooked {
+		t.Errorf("Value not match\nactual = %q\nexpected = %q", actual, hooked)
+	}
+	actual, err := lock.Read(dir)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if !reflect.DeepEqual(actual.Files, recorded.Files) || !reflect.DeepEqual(actual.Rendered, recorded.Rendered) {
+		t.Errorf("Value not match\nactual = %+v\nexpected = %+v", actual, recorded)
+	}
+}
@@ -0,0 +1,53 @@
+package version
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Version is overridden at build time with
+// -ldflags "-X github.com/smilepakawat/goat/internal/version.Version=x.y.z".
+var Version = "0.2.0"
+
+// Compare compares two dotted versions (an optional leading "v" and any
+// pre-release suffix are ignored) and returns -1, 0 or +1.
+func Compare(a, b string) (int, error) {
+	pa, err := parse(a)
+	if err != nil {
+		return 0, err
+	}
+	pb, err := parse(b)
+	if err != nil {
+		return 0, err
+	}
+	for i := range pa {
+		if pa[i] != pb[i] {
+			if pa[i] < pb[i] {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func parse(v string) ([3]int, error) {
+	var parts [3]int
+	trimmed := strings.TrimPrefix(v, "v")
+	if i := strings.IndexAny(trimmed, "-+"); i >= 0 {
+		trimmed = trimmed[:i]
+	}
+	fields := strings.Split(trimmed, ".")
+	if len(fields) > 3 || trimmed == "" {
+		return parts, fmt.Errorf("invalid version %q", v)
+	}
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return parts, fmt.Errorf("invalid version %q", v)
+		}
+		parts[i] = n
+	}
+	return parts, nil
+}
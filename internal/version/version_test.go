@@ -0,0 +1,32 @@
+package version
+
+import "testing"
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name        string
+		a           string
+		b           string
+		expectValue int
+		wantErr     bool
+	}{
+		{name: "equal", a: "1.2.3", b: "v1.2.3", expectValue: 0},
+		{name: "older patch", a: "1.2.3", b: "1.2.10", expectValue: -1},
+		{name: "newer minor", a: "1.10", b: "1.9.9", expectValue: 1},
+		{name: "pre-release suffix", a: "v0.2.0-rc.1", b: "0.2.0", expectValue: 0},
+		{name: "invalid version", a: "latest", b: "0.2.0", wantErr: true},
+		{name: "too many parts", a: "1.2.3.4", b: "0.2.0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actual, err := Compare(tt.a, tt.b)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Compare() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if actual != tt.expectValue {
+				t.Errorf("Value not match\nactual = %v\nexpected = %v", actual, tt.expectValue)
+			}
+		})
+	}
+}
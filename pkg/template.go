@@ -2,5 +2,5 @@ package pkg
 
 import "embed"
 
-//go:embed templates
+//go:embed all:templates
 var Templates embed.FS
\ No newline at end of file